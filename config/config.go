@@ -1,8 +1,17 @@
 package config
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
+	"go/token"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 )
@@ -56,21 +65,216 @@ func (cfg Config) Merge(ocfg Config) Config {
 	if ocfg.HTTPStatusCodeWhitelist != nil {
 		cfg.HTTPStatusCodeWhitelist = mergeLists(cfg.HTTPStatusCodeWhitelist, ocfg.HTTPStatusCodeWhitelist)
 	}
+	if ocfg.Severity != nil {
+		cfg.Severity = mergeMaps(cfg.Severity, ocfg.Severity)
+	}
+	if ocfg.GoVersion != "" {
+		cfg.GoVersion = ocfg.GoVersion
+	}
+	if ocfg.GeneratedFiles != nil {
+		cfg.GeneratedFiles = mergeLists(cfg.GeneratedFiles, ocfg.GeneratedFiles)
+	}
+	if ocfg.GeneratedHeaders != nil {
+		cfg.GeneratedHeaders = mergeLists(cfg.GeneratedHeaders, ocfg.GeneratedHeaders)
+	}
+	if ocfg.CheckPatterns != nil {
+		cfg.CheckPatterns = append(cfg.CheckPatterns[:len(cfg.CheckPatterns):len(cfg.CheckPatterns)], ocfg.CheckPatterns...)
+	}
+	if ocfg.CheckerConfig != nil {
+		out := make(map[string]map[string]interface{}, len(cfg.CheckerConfig)+len(ocfg.CheckerConfig))
+		for k, v := range cfg.CheckerConfig {
+			out[k] = v
+		}
+		for k, v := range ocfg.CheckerConfig {
+			out[k] = mergeTables(out[k], v)
+		}
+		cfg.CheckerConfig = out
+	}
+	if ocfg.CheckerSections != nil {
+		cfg.CheckerSections = append(cfg.CheckerSections[:len(cfg.CheckerSections):len(cfg.CheckerSections)], ocfg.CheckerSections...)
+	}
+	if ocfg.FilterGenerated != nil {
+		out := make(map[string]bool, len(cfg.FilterGenerated)+len(ocfg.FilterGenerated))
+		for k, v := range cfg.FilterGenerated {
+			out[k] = v
+		}
+		for k, v := range ocfg.FilterGenerated {
+			out[k] = v
+		}
+		cfg.FilterGenerated = out
+	}
 	return cfg
 }
 
-type Config struct {
-	// TODO(dh): this implementation makes it impossible for external
-	// clients to add their own checkers with configuration. At the
-	// moment, we don't really care about that; we don't encourage
-	// that people use this package. In the future, we may. The
-	// obvious solution would be using map[string]interface{}, but
-	// that's obviously subpar.
+// mergeMaps returns the union of a and b, preferring the values of
+// b.
+func mergeMaps(a, b map[string]string) map[string]string {
+	out := make(map[string]string, len(a)+len(b))
+	for k, v := range a {
+		out[k] = v
+	}
+	for k, v := range b {
+		out[k] = v
+	}
+	return out
+}
 
+type Config struct {
 	Checks                  []string `toml:"checks"`
 	Initialisms             []string `toml:"initialisms"`
 	DotImportWhitelist      []string `toml:"dot_import_whitelist"`
 	HTTPStatusCodeWhitelist []string `toml:"http_status_code_whitelist"`
+	// Severity maps checks to the severity of their problems, which
+	// is one of "error", "warning", "info", "hint" and "off". Keys
+	// use the same syntax as Checks. When several keys match a
+	// check, the most specific one wins.
+	Severity map[string]string `toml:"severity"`
+	// GoVersion is the Go version to target, in the format "1.x".
+	// It overrides the version declared by the module's go.mod.
+	GoVersion string `toml:"go"`
+	// GeneratedFiles are patterns of files to treat as generated,
+	// in addition to files with a "Code generated ... DO NOT EDIT."
+	// header. Patterns without a slash match file names, other
+	// patterns match the import path joined with the file name.
+	// Patterns ending in "/..." match all files in a package and its
+	// subpackages.
+	GeneratedFiles []string `toml:"generated_files"`
+	// GeneratedHeaders are regular expressions. Files that have a
+	// line matching any of them are treated as generated.
+	GeneratedHeaders []string `toml:"generated_headers"`
+	// FilterGenerated overrides, per check, whether problems in
+	// generated files are suppressed. Keys use the same syntax as
+	// Severity.
+	FilterGenerated map[string]bool `toml:"filter_generated"`
+	// CheckerConfig maps the names of checkers to their sections,
+	// [checker.<name>], which are decoded on demand by Section.
+	CheckerConfig map[string]map[string]interface{} `toml:"checker"`
+
+	// CheckPatterns are the check names and globs used by the
+	// configuration files, for validation against the available
+	// checks.
+	CheckPatterns []CheckPattern `toml:"-"`
+	// CheckerSections are the checker sections of the configuration
+	// files, for validation against the checkers' configuration
+	// types.
+	CheckerSections []Section `toml:"-"`
+
+	// Overrides are sections that only apply to some packages. They
+	// are resolved by LoadPackage and never part of a loaded
+	// configuration.
+	Overrides []Override `toml:"override"`
+}
+
+// A CheckPattern is a check name or glob in a configuration file.
+type CheckPattern struct {
+	Pattern  string
+	Position token.Position
+}
+
+// A Problem is a mistake in a configuration file.
+type Problem struct {
+	Position token.Position
+	Text     string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s", p.Position, p.Text)
+}
+
+// Error is returned for invalid configuration files.
+type Error struct {
+	Problems []Problem
+}
+
+func (err *Error) Error() string {
+	msgs := make([]string, len(err.Problems))
+	for i, p := range err.Problems {
+		msgs[i] = p.String()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// An Override is a section of a configuration file that applies to
+// the packages matched by any of its patterns. It is merged on top of
+// the file's other settings. Patterns are matched in the manner of
+// the go tool: "..." matches any string, including slashes, while *
+// and ? don't match slashes.
+type Override struct {
+	// Paths are patterns of package directories, relative to the
+	// directory of the configuration file.
+	Paths []string `toml:"paths"`
+	// Packages are patterns of import paths.
+	Packages []string `toml:"packages"`
+
+	Checks                  []string          `toml:"checks"`
+	Initialisms             []string          `toml:"initialisms"`
+	DotImportWhitelist      []string          `toml:"dot_import_whitelist"`
+	HTTPStatusCodeWhitelist []string          `toml:"http_status_code_whitelist"`
+	Severity                map[string]string `toml:"severity"`
+
+	CheckerConfig map[string]map[string]interface{} `toml:"checker"`
+}
+
+// matches reports whether the override applies to the package with
+// import path pkgpath in dir. base is the directory of the
+// configuration file that contains the override.
+func (o Override) matches(base, dir, pkgpath string) bool {
+	if rel, err := filepath.Rel(base, dir); err == nil {
+		rel = filepath.ToSlash(rel)
+		for _, pattern := range o.Paths {
+			if matchPattern(strings.TrimPrefix(pattern, "./"), rel) {
+				return true
+			}
+		}
+	}
+	if pkgpath == "" {
+		return false
+	}
+	pkgpath = strings.TrimSuffix(pkgpath, "_test")
+	for _, pattern := range o.Packages {
+		if matchPattern(pattern, pkgpath) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o Override) config() Config {
+	return Config{
+		Checks:                  o.Checks,
+		Initialisms:             o.Initialisms,
+		DotImportWhitelist:      o.DotImportWhitelist,
+		HTTPStatusCodeWhitelist: o.HTTPStatusCodeWhitelist,
+		Severity:                o.Severity,
+		CheckerConfig:           o.CheckerConfig,
+	}
+}
+
+// matchPattern reports whether name matches pattern. A trailing
+// "/..." also matches the empty string, so that "foo/..." matches
+// "foo".
+func matchPattern(pattern, name string) bool {
+	var re strings.Builder
+	re.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "/...") && i+4 == len(pattern):
+			re.WriteString("(/.*)?")
+			i += 3
+		case strings.HasPrefix(pattern[i:], "..."):
+			re.WriteString(".*")
+			i += 2
+		case pattern[i] == '*':
+			re.WriteString("[^/]*")
+		case pattern[i] == '?':
+			re.WriteString("[^/]")
+		default:
+			re.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	re.WriteString("$")
+	ok, _ := regexp.MatchString(re.String(), name)
+	return ok
 }
 
 var defaultConfig = Config{
@@ -86,77 +290,244 @@ var defaultConfig = Config{
 	},
 	DotImportWhitelist:      []string{},
 	HTTPStatusCodeWhitelist: []string{"200", "400", "404", "500"},
+	GeneratedFiles:          []string{},
+	GeneratedHeaders:        []string{},
+}
+
+// DefaultChecks returns the checks that are enabled when no
+// configuration says otherwise.
+func DefaultChecks() []string {
+	return append([]string(nil), defaultConfig.Checks...)
 }
 
 const configName = "staticcheck.conf"
 
-func parseConfigs(dir string) ([]Config, error) {
-	var out []Config
+// parseConfigs returns the configurations that apply to the package
+// with import path pkgpath in dir, from the least to the most
+// specific. Each configuration file is followed by its overrides
+// that match the package. Files with problems are skipped. sources
+// describes where each configuration came from.
+func parseConfigs(dir, pkgpath string) (confs []Config, sources []string, problems []Problem, err error) {
+	type layer struct {
+		cfg    Config
+		source string
+	}
+	var files [][]layer
 
-	// TODO(dh): consider stopping at the GOPATH/module boundary
+	pkgdir := dir
+	// Configuration doesn't apply across module boundaries.
+	root := ModuleRoot(dir)
 	for dir != "" {
-		f, err := os.Open(filepath.Join(dir, configName))
+		path := filepath.Join(dir, configName)
+		f, err := os.Open(path)
 		if os.IsNotExist(err) {
 			ndir := filepath.Dir(dir)
-			if ndir == dir {
+			if ndir == dir || dir == root {
 				break
 			}
 			dir = ndir
 			continue
 		}
 		if err != nil {
-			return nil, err
+			return nil, nil, nil, err
 		}
-		var cfg Config
-		_, err = toml.DecodeReader(f, &cfg)
+		b, err := ioutil.ReadAll(f)
 		f.Close()
 		if err != nil {
-			return nil, err
+			return nil, nil, nil, err
+		}
+		cfg, ps := parseConfig(path, string(b))
+		problems = append(problems, ps...)
+		if ps != nil {
+			// Don't apply any part of a broken file.
+			cfg = Config{CheckPatterns: cfg.CheckPatterns, CheckerSections: cfg.CheckerSections}
+		}
+		group := []layer{{cfg, path}}
+		for i, o := range cfg.Overrides {
+			if o.matches(dir, pkgdir, pkgpath) {
+				group = append(group, layer{o.config(), fmt.Sprintf("%s (override %d)", path, i+1)})
+			}
 		}
-		out = append(out, cfg)
+		files = append(files, group)
 		ndir := filepath.Dir(dir)
-		if ndir == dir {
+		if ndir == dir || dir == root {
 			break
 		}
 		dir = ndir
 	}
-	out = append(out, defaultConfig)
-	if len(out) < 2 {
-		return out, nil
-	}
-	for i := 0; i < len(out)/2; i++ {
-		out[i], out[len(out)-1-i] = out[len(out)-1-i], out[i]
+
+	confs = []Config{defaultConfig}
+	sources = []string{"default"}
+	for i := len(files) - 1; i >= 0; i-- {
+		for _, l := range files[i] {
+			confs = append(confs, l.cfg)
+			sources = append(sources, l.source)
+		}
 	}
-	return out, nil
+	return confs, sources, problems, nil
+}
+
+// validSeverities are the severities that may be configured.
+var validSeverities = map[string]bool{
+	"error":   true,
+	"warning": true,
+	"info":    true,
+	"hint":    true,
+	"off":     true,
 }
 
-func mergeConfigs(confs []Config) Config {
-	if len(confs) == 0 {
-		// This shouldn't happen because we always have at least a
-		// default config.
-		panic("trying to merge zero configs")
+// parseConfig parses the configuration file filename, which contains
+// text.
+func parseConfig(filename, text string) (Config, []Problem) {
+	src := &source{filename: filename, text: text}
+	var problems []Problem
+	report := func(offset int, format string, args ...interface{}) {
+		problems = append(problems, Problem{Position: src.position(offset), Text: fmt.Sprintf(format, args...)})
+	}
+
+	var cfg Config
+	md, err := toml.Decode(text, &cfg)
+	if err != nil {
+		msg := err.Error()
+		if i := strings.Index(msg, "): "); strings.HasPrefix(msg, "Near line ") && i >= 0 {
+			msg = msg[i+len("): "):]
+		}
+		return Config{}, []Problem{{Position: src.parseErrorPosition(err), Text: msg}}
+	}
+	whole := region{0, len(text)}
+	for _, key := range md.Undecoded() {
+		r := src.top()
+		if len(key) > 1 {
+			r, _ = src.table(key[0], 0)
+		}
+		offset := src.key(r, key[len(key)-1])
+		if offset < 0 {
+			offset = src.key(whole, key[len(key)-1])
+		}
+		report(offset, "unknown configuration key %q", key.String())
+	}
+
+	// checks validates the check patterns of a section in r.
+	checks := func(r region, checks []string, severity map[string]string, filter map[string]bool) {
+		add := func(offset int, pattern string) {
+			pattern = strings.TrimPrefix(pattern, "-")
+			if pattern == "inherit" || pattern == "all" || pattern == "*" {
+				return
+			}
+			cfg.CheckPatterns = append(cfg.CheckPatterns, CheckPattern{Pattern: pattern, Position: src.position(offset)})
+		}
+		for _, check := range checks {
+			add(src.value(r.after(src.key(r, "checks")), check), check)
+		}
+		for pattern, sev := range severity {
+			offset := src.value(r.after(src.key(r, "severity")), pattern)
+			add(offset, pattern)
+			if !validSeverities[sev] {
+				report(offset, "invalid severity %q for %s, must be one of error, warning, info, hint and off", sev, pattern)
+			}
+		}
+		for pattern := range filter {
+			add(src.value(r.after(src.key(r, "filter_generated")), pattern), pattern)
+		}
+	}
+	checks(whole, cfg.Checks, cfg.Severity, cfg.FilterGenerated)
+	cfg.CheckerSections = src.sections(whole, "", cfg.CheckerConfig)
+	for i, o := range cfg.Overrides {
+		r, _ := src.table("override", i)
+		checks(r, o.Checks, o.Severity, nil)
+		cfg.CheckerSections = append(cfg.CheckerSections, src.sections(r, "override", o.CheckerConfig)...)
+		if len(o.Paths) == 0 && len(o.Packages) == 0 {
+			report(r.start, "override doesn't have any paths or packages")
+		}
 	}
-	if len(confs) == 1 {
-		return confs[0]
+
+	for _, header := range cfg.GeneratedHeaders {
+		if _, err := regexp.Compile(header); err != nil {
+			report(src.value(whole.after(src.key(whole, "generated_headers")), header), "invalid generated header: %s", err)
+		}
 	}
-	conf := confs[0]
-	for _, oconf := range confs[1:] {
-		conf = conf.Merge(oconf)
+	if cfg.GoVersion != "" {
+		if _, err := ParseGoVersion(cfg.GoVersion); err != nil {
+			report(src.key(src.top(), "go"), "%s", err)
+		}
 	}
-	return conf
+	sort.Slice(problems, func(i, j int) bool {
+		return problems[i].Position.Offset < problems[j].Position.Offset
+	})
+	return cfg, problems
 }
 
+// Load loads the configuration of the directory dir. Overrides that
+// match packages by import path aren't applied; use LoadPackage for
+// that.
 func Load(dir string) (Config, error) {
-	confs, err := parseConfigs(dir)
-	if err != nil {
-		return Config{}, err
+	return LoadPackage(dir, "")
+}
+
+// LoadPackage loads the configuration of the package with import path
+// pkgpath in dir.
+//
+// If any of the configuration files are invalid, the error is an
+// *Error, and the configuration is that of the remaining files.
+func LoadPackage(dir, pkgpath string) (Config, error) {
+	conf, _, err := Trace(dir, pkgpath)
+	return conf, err
+}
+
+// ParseGoVersion parses a Go version of the form "1.x", returning x.
+// A trailing patch version, as in "1.21.0", is ignored.
+func ParseGoVersion(s string) (int, error) {
+	if !strings.HasPrefix(s, "1.") {
+		return 0, errors.New("invalid Go version " + strconv.Quote(s))
+	}
+	minor := strings.SplitN(s[2:], ".", 2)[0]
+	v, err := strconv.Atoi(minor)
+	if err != nil || v < 0 {
+		return 0, errors.New("invalid Go version " + strconv.Quote(s))
 	}
-	conf := mergeConfigs(confs)
+	return v, nil
+}
 
-	conf.Checks = normalizeList(conf.Checks)
-	conf.Initialisms = normalizeList(conf.Initialisms)
-	conf.DotImportWhitelist = normalizeList(conf.DotImportWhitelist)
-	conf.HTTPStatusCodeWhitelist = normalizeList(conf.HTTPStatusCodeWhitelist)
+// ModuleRoot returns the directory containing the go.mod file of the
+// module that dir belongs to, or the empty string if dir isn't part
+// of a module.
+func ModuleRoot(dir string) string {
+	for {
+		if fi, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil && !fi.IsDir() {
+			return dir
+		}
+		ndir := filepath.Dir(dir)
+		if ndir == dir {
+			return ""
+		}
+		dir = ndir
+	}
+}
 
-	return conf, nil
+// ModuleGoVersion returns the minor Go version declared by the go
+// directive of the module that dir belongs to. It returns false if
+// dir isn't part of a module or the module doesn't declare a version.
+func ModuleGoVersion(dir string) (int, bool) {
+	root := ModuleRoot(dir)
+	if root == "" {
+		return 0, false
+	}
+	f, err := os.Open(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 2 || fields[0] != "go" {
+			continue
+		}
+		v, err := ParseGoVersion(fields[1])
+		if err != nil {
+			return 0, false
+		}
+		return v, true
+	}
+	return 0, false
 }
@@ -0,0 +1,384 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"foo", "foo", true},
+		{"foo", "foobar", false},
+		{"foo/...", "foo", true},
+		{"foo/...", "foo/bar/baz", true},
+		{"foo/...", "foobar", false},
+		{"*/internal/pb/*", "example.com/internal/pb/v1", true},
+		{"*/internal/pb/*", "example.com/x/internal/pb/v1", false},
+		{".../internal/pb/...", "example.com/x/internal/pb/v1", true},
+		{"cmd/?", "cmd/a", true},
+		{"cmd/?", "cmd/ab", false},
+	}
+	for _, tt := range tests {
+		if got := matchPattern(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchPattern(%q, %q) = %t, want %t", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(path, data string) {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.mod", "module example.com\n")
+	write(configName, `
+checks = ["all"]
+
+[[override]]
+packages = [".../internal/pb/..."]
+checks = ["inherit", "-ST1003"]
+severity = { "SA*" = "warning" }
+
+[[override]]
+paths = ["cmd/..."]
+initialisms = ["inherit", "CLI"]
+`)
+	write("sub/"+configName, `checks = ["inherit", "-SA1000"]`)
+
+	tests := []struct {
+		dir, pkgpath string
+		checks       []string
+		severity     map[string]string
+		cli          bool
+	}{
+		{"", "example.com", []string{"all"}, nil, false},
+		{"internal/pb/v1", "example.com/internal/pb/v1", []string{"all", "-ST1003"}, map[string]string{"SA*": "warning"}, false},
+		{"internal/pb/v1", "", []string{"all"}, nil, false},
+		{"cmd/tool", "example.com/cmd/tool", []string{"all"}, nil, true},
+		// Overrides apply before more specific configuration files.
+		{"sub/internal/pb", "example.com/sub/internal/pb", []string{"all", "-ST1003", "-SA1000"}, map[string]string{"SA*": "warning"}, false},
+	}
+	for _, tt := range tests {
+		cfg, err := LoadPackage(filepath.Join(dir, tt.dir), tt.pkgpath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(cfg.Checks, tt.checks) {
+			t.Errorf("%s: got checks %q, want %q", tt.dir, cfg.Checks, tt.checks)
+		}
+		if !reflect.DeepEqual(cfg.Severity, tt.severity) {
+			t.Errorf("%s: got severity %v, want %v", tt.dir, cfg.Severity, tt.severity)
+		}
+		cli := false
+		for _, s := range cfg.Initialisms {
+			if s == "CLI" {
+				cli = true
+			}
+		}
+		if cli != tt.cli {
+			t.Errorf("%s: CLI is an initialism: %t, want %t", tt.dir, cli, tt.cli)
+		}
+		if cfg.Overrides != nil {
+			t.Errorf("%s: loaded configuration has overrides", tt.dir)
+		}
+	}
+}
+
+func TestProblems(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{
+			"checks = [\"all\"\n",
+			[]string{"staticcheck.conf:1:1"},
+		},
+		{
+			"checks = [\"all\"]\nchekcs = [\"-ST1000\"]\n",
+			[]string{`staticcheck.conf:2:1: unknown configuration key "chekcs"`},
+		},
+		{
+			"go = \"2\"\n\n[severity]\nSA1000 = \"fatal\"\n",
+			[]string{
+				`staticcheck.conf:1:1: invalid Go version "2"`,
+				`staticcheck.conf:4:1: invalid severity "fatal" for SA1000, must be one of error, warning, info, hint and off`,
+			},
+		},
+		{
+			"checks = [\"all\"]\n\n[[override]]\npaths = [\"a\"]\n\n[[override]]\npackages = [\"b\"]\nchecsk = [\"-S1000\"]\n",
+			[]string{`staticcheck.conf:8:1: unknown configuration key "override.checsk"`},
+		},
+		{
+			"generated_headers = [\"(\"]\n",
+			[]string{"staticcheck.conf:1:22: invalid generated header: error parsing regexp: missing closing ): `(`"},
+		},
+	}
+	for _, tt := range tests {
+		_, problems := parseConfig("staticcheck.conf", tt.text)
+		var got []string
+		for _, p := range problems {
+			got = append(got, p.String())
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%q: got problems %q, want %q", tt.text, got, tt.want)
+			continue
+		}
+		for i := range got {
+			// Syntax errors are only checked for their position.
+			if got[i] != tt.want[i] && !strings.HasPrefix(got[i], tt.want[i]+": ") {
+				t.Errorf("%q: got problem %q, want %q", tt.text, got[i], tt.want[i])
+			}
+		}
+	}
+
+	_, problems := parseConfig("staticcheck.conf", "checks = [\"all\", \"-SA1000\"]\n\n[[override]]\npaths = [\"x\"]\nseverity = { \"S1*\" = \"off\" }\n")
+	if len(problems) != 0 {
+		t.Errorf("unexpected problems %v", problems)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conf := filepath.Join(dir, configName)
+	text := "checks = [\"inherit\", \"-SA1000\"]\n\n[[override]]\npaths = [\"...\"]\nseverity = { \"SA*\" = \"warning\" }\n"
+	if err := ioutil.WriteFile(conf, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, o, err := Trace(dir, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(o.Checks) != len(cfg.Checks) {
+		t.Fatalf("got %d origins for %d checks", len(o.Checks), len(cfg.Checks))
+	}
+	if got := o.Checks[len(o.Checks)-1]; got != conf {
+		t.Errorf("-SA1000 comes from %q, want %q", got, conf)
+	}
+	if got := o.Checks[0]; got != "default" {
+		t.Errorf("%s comes from %q, want %q", cfg.Checks[0], got, "default")
+	}
+	if got, want := o.Severity["SA*"], conf+" (override 1)"; got != want {
+		t.Errorf("SA* severity comes from %q, want %q", got, want)
+	}
+
+	cfg, o = cfg.MergeTraced(o, Config{Checks: []string{"inherit", "-SA1000", "-S1000"}}, "flag")
+	cfg, o = cfg.Normalize(o)
+	if got := cfg.Checks[len(cfg.Checks)-1]; got != "-S1000" {
+		t.Errorf("last check is %q, want %q", got, "-S1000")
+	}
+	if got := o.Checks[len(o.Checks)-1]; got != "flag" {
+		t.Errorf("-S1000 comes from %q, want %q", got, "flag")
+	}
+}
+
+func TestSections(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(path, data string) {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.mod", "module example.com\n")
+	write(configName, `
+[checker.banned]
+functions = ["inherit", "os.Exit"]
+max = 3
+
+[[override]]
+paths = ["legacy/..."]
+
+[override.checker.banned]
+functions = []
+`)
+	write("sub/"+configName, `
+[checker.banned]
+functions = ["inherit", "log.Fatal"]
+`)
+
+	type bannedConfig struct {
+		Functions []string `toml:"functions"`
+		Max       int      `toml:"max"`
+	}
+	tests := []struct {
+		dir  string
+		want bannedConfig
+	}{
+		{"", bannedConfig{[]string{"panic", "os.Exit"}, 3}},
+		{"sub", bannedConfig{[]string{"panic", "os.Exit", "log.Fatal"}, 3}},
+		{"legacy/x", bannedConfig{[]string{}, 3}},
+	}
+	for _, tt := range tests {
+		cfg, err := LoadPackage(filepath.Join(dir, tt.dir), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := bannedConfig{Functions: []string{"panic"}}
+		if err := cfg.Section("banned", &got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.dir, got, tt.want)
+		}
+	}
+
+	cfg, _ := parseConfig(configName, "checks = [\"all\"]\n\n[checker.banned]\nfunctoins = [\"os.Exit\"]\nmax = \"3\"\n\n[checker.other]\nfoo = 1\n")
+	var problems []Problem
+	for _, s := range cfg.CheckerSections {
+		if s.Checker != "banned" {
+			continue
+		}
+		problems = append(problems, s.Validate(&bannedConfig{})...)
+	}
+	// The error itself comes from the TOML decoder; only check that
+	// it is attributed to the section.
+	if len(problems) != 1 ||
+		problems[0].Position.String() != "staticcheck.conf:3:1" ||
+		!strings.HasPrefix(problems[0].Text, "invalid configuration of banned: ") {
+		t.Errorf("got problems %q, want one invalid configuration of banned at staticcheck.conf:3:1", problems)
+	}
+
+	cfg, _ = parseConfig(configName, "[checker.banned]\nfunctoins = [\"os.Exit\"]\n\n[[override]]\npaths = [\"x\"]\n\n[override.checker.banned]\nmax = 1\nmxa = 2\n")
+	var got []string
+	for _, s := range cfg.CheckerSections {
+		for _, p := range s.Validate(&bannedConfig{}) {
+			got = append(got, p.String())
+		}
+	}
+	want := []string{
+		`staticcheck.conf:2:1: unknown configuration key "checker.banned.functoins"`,
+		`staticcheck.conf:9:1: unknown configuration key "checker.banned.mxa"`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got problems %q, want %q", got, want)
+	}
+}
+
+func TestParseGoVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"1.0", 0},
+		{"1.11", 11},
+		{"1.21.0", 21},
+		{"1.21rc1", -1},
+		{"1.", -1},
+		{"1.-1", -1},
+		{"2.0", -1},
+		{"go1.12", -1},
+		{"", -1},
+	}
+	for _, tt := range tests {
+		got, err := ParseGoVersion(tt.in)
+		if tt.want == -1 {
+			if err == nil {
+				t.Errorf("ParseGoVersion(%q) = %d, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseGoVersion(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestModuleGoVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(path, data string) {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("mod/go.mod", "module example.com\n\ngo 1.12\n")
+	write("mod/pkg/p.go", "package pkg\n")
+	write("nogo/go.mod", "module example.com/nogo\n")
+	write("badgo/go.mod", "module example.com/badgo\n\ngo 2\n")
+	write("nomod/p.go", "package nomod\n")
+	// Configuration above the module root doesn't apply to it, and
+	// neither does its Go version.
+	write(configName, "go = \"1.5\"\n")
+
+	tests := []struct {
+		dir  string
+		want int
+		ok   bool
+	}{
+		{"mod", 12, true},
+		{"mod/pkg", 12, true},
+		{"nogo", 0, false},
+		{"badgo", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ModuleGoVersion(filepath.Join(dir, tt.dir))
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: got %d, %t, want %d, %t", tt.dir, got, ok, tt.want, tt.ok)
+		}
+		cfg, err := Load(filepath.Join(dir, tt.dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.GoVersion != "" {
+			t.Errorf("%s: got configured Go version %q from outside the module", tt.dir, cfg.GoVersion)
+		}
+	}
+
+	// Outside of a module, there is no module version, but the
+	// configuration of parent directories applies.
+	if ModuleRoot(dir) == "" {
+		if _, ok := ModuleGoVersion(filepath.Join(dir, "nomod")); ok {
+			t.Error("nomod: got a module Go version outside of a module")
+		}
+		cfg, err := Load(filepath.Join(dir, "nomod"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.GoVersion != "1.5" {
+			t.Errorf("nomod: got configured Go version %q, want %q", cfg.GoVersion, "1.5")
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// A Section is the section of a checker in a configuration file, such
+// as [checker.example]. Sections are kept per file so that they can
+// be validated once the checkers' configuration types are known.
+type Section struct {
+	Checker  string
+	Position token.Position
+	// Keys maps the keys of the section to their positions.
+	Keys map[string]token.Position
+
+	data map[string]interface{}
+}
+
+// Validate decodes the section into v, a pointer to the checker's
+// configuration type, and reports unknown keys and values of the
+// wrong type.
+func (s Section) Validate(v interface{}) []Problem {
+	md, err := decodeSection(resolveInherit(s.data), v)
+	if err != nil {
+		return []Problem{{Position: s.Position, Text: fmt.Sprintf("invalid configuration of %s: %s", s.Checker, err)}}
+	}
+	var problems []Problem
+	for _, key := range md.Undecoded() {
+		pos, ok := s.Keys[key[0]]
+		if !ok {
+			pos = s.Position
+		}
+		problems = append(problems, Problem{
+			Position: pos,
+			Text:     fmt.Sprintf("unknown configuration key %q", "checker."+s.Checker+"."+key.String()),
+		})
+	}
+	sort.Slice(problems, func(i, j int) bool {
+		return problems[i].Position.Offset < problems[j].Position.Offset
+	})
+	return problems
+}
+
+// Section decodes the configuration of the checker name into v,
+// which must be a pointer to a struct. The values of v are defaults
+// that the configuration files are merged on top of, so that lists
+// can inherit them. Unknown keys are ignored; they are reported as
+// problems of the configuration instead.
+func (cfg Config) Section(name string, v interface{}) error {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(v); err != nil {
+		return err
+	}
+	var defaults map[string]interface{}
+	if _, err := toml.Decode(buf.String(), &defaults); err != nil {
+		return err
+	}
+	_, err := decodeSection(resolveInherit(mergeTables(defaults, cfg.CheckerConfig[name])), v)
+	return err
+}
+
+// decodeSection decodes data into v. The TOML decoder can only
+// decode text, so data is encoded first.
+func decodeSection(data map[string]interface{}, v interface{}) (toml.MetaData, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(data); err != nil {
+		return toml.MetaData{}, err
+	}
+	return toml.Decode(buf.String(), v)
+}
+
+// mergeTables merges the checker sections a and b the same way Merge
+// merges configurations: tables are merged recursively, lists replace
+// the element "inherit" with the inherited list, and other values of
+// b replace those of a. Lists that have nothing to inherit keep
+// "inherit", so that they can inherit the checker's defaults later.
+func mergeTables(a, b map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(a)+len(b))
+	for k, v := range a {
+		out[k] = v
+	}
+	for k, v := range b {
+		old, ok := out[k]
+		if !ok {
+			out[k] = v
+			continue
+		}
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if old, ok := old.(map[string]interface{}); ok {
+				out[k] = mergeTables(old, v)
+				continue
+			}
+		case []interface{}:
+			if old, ok := old.([]interface{}); ok {
+				l := make([]interface{}, 0, len(old)+len(v))
+				for _, el := range v {
+					if el == "inherit" {
+						l = append(l, old...)
+					} else {
+						l = append(l, el)
+					}
+				}
+				out[k] = l
+				continue
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// resolveInherit returns a copy of a checker section without the
+// elements "inherit" of lists that had nothing to inherit.
+func resolveInherit(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			out[k] = resolveInherit(v)
+		case []interface{}:
+			l := make([]interface{}, 0, len(v))
+			for _, el := range v {
+				if el != "inherit" {
+					l = append(l, el)
+				}
+			}
+			out[k] = l
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// sections returns the checker sections of a configuration file in r.
+// prefix is the name of the table that contains r, if any, such as
+// "override".
+func (src *source) sections(r region, prefix string, conf map[string]map[string]interface{}) []Section {
+	names := make([]string, 0, len(conf))
+	for name := range conf {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out []Section
+	for _, name := range names {
+		header := strings.TrimPrefix(prefix+".checker."+name, ".")
+		sr, ok := src.tableIn(r, header)
+		offset := sr.start
+		if !ok {
+			// The section is an inline table or uses dotted keys.
+			sr = r.after(src.key(r, "checker"))
+			offset = src.key(sr, name)
+			sr = sr.after(offset)
+		}
+		s := Section{
+			Checker:  name,
+			Position: src.position(offset),
+			Keys:     map[string]token.Position{},
+			data:     conf[name],
+		}
+		for key := range conf[name] {
+			if offset := src.key(sr, key); offset >= 0 {
+				s.Keys[key] = src.position(offset)
+			}
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// tableIn is like table, but only finds the first occurrence of the
+// table in r.
+func (src *source) tableIn(r region, name string) (region, bool) {
+	names, offsets := src.headers()
+	for i := range names {
+		if offsets[i] < r.start || offsets[i] >= r.end {
+			continue
+		}
+		n := 0
+		for j := 0; j < i; j++ {
+			if names[j] == name {
+				n++
+			}
+		}
+		if names[i] == name {
+			return src.table(name, n)
+		}
+	}
+	return region{}, false
+}
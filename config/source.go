@@ -0,0 +1,127 @@
+package config
+
+import (
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A source is the text of a configuration file. The TOML decoder
+// doesn't retain positions, so source finds keys and values in the
+// text to position problems.
+type source struct {
+	filename string
+	text     string
+}
+
+// position returns the position of the byte at offset. A negative
+// offset denotes the file as a whole.
+func (src *source) position(offset int) token.Position {
+	if offset < 0 {
+		return token.Position{Filename: src.filename}
+	}
+	line := 1 + strings.Count(src.text[:offset], "\n")
+	col := offset - strings.LastIndex(src.text[:offset], "\n")
+	return token.Position{Filename: src.filename, Offset: offset, Line: line, Column: col}
+}
+
+// A region is a range of offsets.
+type region struct {
+	start, end int
+}
+
+var headerRe = regexp.MustCompile(`(?m)^[ \t]*\[\[?[ \t]*([^\]]+?)[ \t]*\]\]?`)
+
+// headers returns the table headers of the file.
+func (src *source) headers() (names []string, offsets []int) {
+	for _, m := range headerRe.FindAllStringSubmatchIndex(src.text, -1) {
+		names = append(names, src.text[m[2]:m[3]])
+		offsets = append(offsets, m[0])
+	}
+	return names, offsets
+}
+
+// top returns the region of keys that don't belong to any table.
+func (src *source) top() region {
+	_, offsets := src.headers()
+	if len(offsets) == 0 {
+		return region{0, len(src.text)}
+	}
+	return region{0, offsets[0]}
+}
+
+// table returns the region of the table with the given name,
+// including its subtables. The i'th occurrence of the header is used,
+// which is useful for arrays of tables.
+func (src *source) table(name string, i int) (region, bool) {
+	names, offsets := src.headers()
+	n := 0
+	for j, hname := range names {
+		if hname != name {
+			continue
+		}
+		if n < i {
+			n++
+			continue
+		}
+		r := region{offsets[j], len(src.text)}
+		for k := j + 1; k < len(names); k++ {
+			if !strings.HasPrefix(names[k], name+".") {
+				r.end = offsets[k]
+				break
+			}
+		}
+		return r, true
+	}
+	return region{}, false
+}
+
+// key returns the offset of the definition of key in r, either as a
+// key/value pair or as a table header, or -1.
+func (src *source) key(r region, key string) int {
+	re := regexp.MustCompile(`(?m)^[ \t]*(?:` + regexp.QuoteMeta(key) + `|"` + regexp.QuoteMeta(key) + `"|'` + regexp.QuoteMeta(key) + `')[ \t]*=` +
+		`|^[ \t]*\[[^\]]*\b` + regexp.QuoteMeta(key) + `[ \t]*\]`)
+	if loc := re.FindStringIndex(src.text[r.start:r.end]); loc != nil {
+		return r.start + loc[0]
+	}
+	return -1
+}
+
+// value returns the offset of the string s in r, quoted or as a bare
+// key, or -1.
+func (src *source) value(r region, s string) int {
+	text := src.text[r.start:r.end]
+	for _, q := range []string{strconv.Quote(s), "'" + s + "'"} {
+		if i := strings.Index(text, q); i >= 0 {
+			return r.start + i
+		}
+	}
+	re := regexp.MustCompile(`(?m)^[ \t]*` + regexp.QuoteMeta(s) + `[ \t]*=`)
+	if loc := re.FindStringIndex(text); loc != nil {
+		return r.start + loc[0]
+	}
+	return -1
+}
+
+// after returns the part of r that follows offset, or r if offset is
+// negative.
+func (r region) after(offset int) region {
+	if offset < 0 {
+		return r
+	}
+	return region{offset, r.end}
+}
+
+var lineRe = regexp.MustCompile(`line (\d+)`)
+
+// parseErrorPosition returns the position of a TOML syntax error,
+// which only reports lines.
+func (src *source) parseErrorPosition(err error) token.Position {
+	m := lineRe.FindStringSubmatch(err.Error())
+	if m == nil {
+		return src.position(-1)
+	}
+	line, _ := strconv.Atoi(m[1])
+	return token.Position{Filename: src.filename, Line: line, Column: 1}
+}
@@ -0,0 +1,150 @@
+package config
+
+// An Origin records where the values of a configuration came from.
+// Sources are the names of configuration files, optionally followed
+// by the number of an [[override]] section, or "default" for the
+// built-in defaults. Lists are parallel to the lists of the
+// configuration, and maps have the same keys.
+type Origin struct {
+	Checks                  []string          `json:"checks"`
+	Initialisms             []string          `json:"initialisms"`
+	DotImportWhitelist      []string          `json:"dot_import_whitelist"`
+	HTTPStatusCodeWhitelist []string          `json:"http_status_code_whitelist"`
+	Severity                map[string]string `json:"severity"`
+	GoVersion               string            `json:"go,omitempty"`
+	GeneratedFiles          []string          `json:"generated_files"`
+	GeneratedHeaders        []string          `json:"generated_headers"`
+	FilterGenerated         map[string]string `json:"filter_generated"`
+	// CheckerConfig records the origins of the keys of checker
+	// sections. Keys that are tables are attributed as a whole to
+	// the most specific source that sets any of their keys.
+	CheckerConfig map[string]map[string]string `json:"checker"`
+}
+
+// Trace is like LoadPackage, but also returns the origins of the
+// configuration's values.
+func Trace(dir, pkgpath string) (Config, Origin, error) {
+	confs, sources, problems, err := parseConfigs(dir, pkgpath)
+	if err != nil {
+		return Config{}, Origin{}, err
+	}
+	var conf Config
+	var o Origin
+	for i, c := range confs {
+		conf, o = conf.MergeTraced(o, c, sources[i])
+	}
+	conf, o = conf.Normalize(o)
+	if problems != nil {
+		return conf, o, &Error{Problems: problems}
+	}
+	return conf, o, nil
+}
+
+// MergeTraced is like Merge, but also computes the origins of the
+// merged configuration. o are the origins of cfg, and source is that
+// of ocfg.
+func (cfg Config) MergeTraced(o Origin, ocfg Config, source string) (Config, Origin) {
+	if ocfg.Checks != nil {
+		o.Checks = traceLists(cfg.Checks, o.Checks, ocfg.Checks, source)
+	}
+	if ocfg.Initialisms != nil {
+		o.Initialisms = traceLists(cfg.Initialisms, o.Initialisms, ocfg.Initialisms, source)
+	}
+	if ocfg.DotImportWhitelist != nil {
+		o.DotImportWhitelist = traceLists(cfg.DotImportWhitelist, o.DotImportWhitelist, ocfg.DotImportWhitelist, source)
+	}
+	if ocfg.HTTPStatusCodeWhitelist != nil {
+		o.HTTPStatusCodeWhitelist = traceLists(cfg.HTTPStatusCodeWhitelist, o.HTTPStatusCodeWhitelist, ocfg.HTTPStatusCodeWhitelist, source)
+	}
+	if ocfg.GeneratedFiles != nil {
+		o.GeneratedFiles = traceLists(cfg.GeneratedFiles, o.GeneratedFiles, ocfg.GeneratedFiles, source)
+	}
+	if ocfg.GeneratedHeaders != nil {
+		o.GeneratedHeaders = traceLists(cfg.GeneratedHeaders, o.GeneratedHeaders, ocfg.GeneratedHeaders, source)
+	}
+	if ocfg.Severity != nil {
+		o.Severity = traceMap(o.Severity, len(ocfg.Severity))
+		for k := range ocfg.Severity {
+			o.Severity[k] = source
+		}
+	}
+	if ocfg.FilterGenerated != nil {
+		o.FilterGenerated = traceMap(o.FilterGenerated, len(ocfg.FilterGenerated))
+		for k := range ocfg.FilterGenerated {
+			o.FilterGenerated[k] = source
+		}
+	}
+	if ocfg.GoVersion != "" {
+		o.GoVersion = source
+	}
+	if ocfg.CheckerConfig != nil {
+		out := make(map[string]map[string]string, len(o.CheckerConfig)+len(ocfg.CheckerConfig))
+		for name, origins := range o.CheckerConfig {
+			out[name] = origins
+		}
+		for name, section := range ocfg.CheckerConfig {
+			origins := traceMap(out[name], len(section))
+			for k := range section {
+				origins[k] = source
+			}
+			out[name] = origins
+		}
+		o.CheckerConfig = out
+	}
+	return cfg.Merge(ocfg), o
+}
+
+// traceLists returns the origins of mergeLists(a, b), where oa are
+// the origins of a, and b comes from source.
+func traceLists(a, oa, b []string, source string) []string {
+	out := make([]string, 0, len(a)+len(b))
+	for _, el := range b {
+		if el == "inherit" {
+			out = append(out, oa...)
+		} else {
+			out = append(out, source)
+		}
+	}
+	return out
+}
+
+// traceMap returns a copy of the origins of a map, with room for n
+// more entries.
+func traceMap(o map[string]string, n int) map[string]string {
+	out := make(map[string]string, len(o)+n)
+	for k, v := range o {
+		out[k] = v
+	}
+	return out
+}
+
+// Normalize resolves the lists of a configuration that was merged
+// with MergeTraced, such as one with configuration from the command
+// line on top of one returned by Trace, keeping o in sync.
+func (cfg Config) Normalize(o Origin) (Config, Origin) {
+	cfg.Checks, o.Checks = normalizeTracedList(cfg.Checks, o.Checks)
+	cfg.Initialisms, o.Initialisms = normalizeTracedList(cfg.Initialisms, o.Initialisms)
+	cfg.DotImportWhitelist, o.DotImportWhitelist = normalizeTracedList(cfg.DotImportWhitelist, o.DotImportWhitelist)
+	cfg.HTTPStatusCodeWhitelist, o.HTTPStatusCodeWhitelist = normalizeTracedList(cfg.HTTPStatusCodeWhitelist, o.HTTPStatusCodeWhitelist)
+	cfg.GeneratedFiles, o.GeneratedFiles = normalizeTracedList(cfg.GeneratedFiles, o.GeneratedFiles)
+	cfg.GeneratedHeaders, o.GeneratedHeaders = normalizeTracedList(cfg.GeneratedHeaders, o.GeneratedHeaders)
+	return cfg, o
+}
+
+// normalizeTracedList is like normalizeList, but also drops the
+// origins of removed elements. Of consecutive duplicates, the first
+// one is kept.
+func normalizeTracedList(list, origins []string) ([]string, []string) {
+	if len(list) < 2 {
+		return normalizeList(list), origins
+	}
+	nlist := []string{list[0]}
+	norigins := []string{origins[0]}
+	for i, el := range list[1:] {
+		if el != list[i] {
+			nlist = append(nlist, el)
+			norigins = append(norigins, origins[i+1])
+		}
+	}
+	return normalizeList(nlist), norigins
+}
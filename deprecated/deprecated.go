@@ -0,0 +1,107 @@
+// Package deprecated contains knowledge about deprecated objects.
+package deprecated // import "honnef.co/go/tools/deprecated"
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// IsDeprecated is a fact recording that an object has been
+// deprecated. Msg is the text following "Deprecated: " in the
+// object's documentation.
+type IsDeprecated struct{ Msg string }
+
+func (*IsDeprecated) AFact() {}
+
+func (d *IsDeprecated) String() string { return "Deprecated: " + d.Msg }
+
+// Objects returns all objects declared in files that have been marked
+// as deprecated in their documentation, mapped to the deprecation
+// message.
+func Objects(files []*ast.File, info *types.Info) map[types.Object]string {
+	out := map[types.Object]string{}
+	var docs []*ast.CommentGroup
+	var names []*ast.Ident
+
+	doDocs := func(names []*ast.Ident, docs []*ast.CommentGroup) {
+		var alt string
+		for _, doc := range docs {
+			if doc == nil {
+				continue
+			}
+			parts := strings.Split(doc.Text(), "\n\n")
+			last := parts[len(parts)-1]
+			if !strings.HasPrefix(last, "Deprecated: ") {
+				continue
+			}
+			alt = last[len("Deprecated: "):]
+			alt = strings.Replace(alt, "\n", " ", -1)
+			break
+		}
+		if alt == "" {
+			return
+		}
+
+		for _, name := range names {
+			obj := info.ObjectOf(name)
+			out[obj] = alt
+		}
+	}
+
+	for _, f := range files {
+		fn := func(node ast.Node) bool {
+			if node == nil {
+				return true
+			}
+			var ret bool
+			switch node := node.(type) {
+			case *ast.GenDecl:
+				switch node.Tok {
+				case token.TYPE, token.CONST, token.VAR:
+					docs = append(docs, node.Doc)
+					return true
+				default:
+					return false
+				}
+			case *ast.FuncDecl:
+				docs = append(docs, node.Doc)
+				names = []*ast.Ident{node.Name}
+				ret = false
+			case *ast.TypeSpec:
+				docs = append(docs, node.Doc)
+				names = []*ast.Ident{node.Name}
+				ret = true
+			case *ast.ValueSpec:
+				docs = append(docs, node.Doc)
+				names = node.Names
+				ret = false
+			case *ast.File:
+				return true
+			case *ast.StructType:
+				for _, field := range node.Fields.List {
+					doDocs(field.Names, []*ast.CommentGroup{field.Doc})
+				}
+				return false
+			case *ast.InterfaceType:
+				for _, field := range node.Methods.List {
+					doDocs(field.Names, []*ast.CommentGroup{field.Doc})
+				}
+				return false
+			default:
+				return false
+			}
+			if len(names) == 0 || len(docs) == 0 {
+				return ret
+			}
+			doDocs(names, docs)
+
+			docs = docs[:0]
+			names = nil
+			return ret
+		}
+		ast.Inspect(f, fn)
+	}
+	return out
+}
@@ -0,0 +1,17 @@
+package errcheck
+
+import "honnef.co/go/tools/lint"
+
+var docs = map[string]*lint.Documentation{
+	"ERR1000": {
+		Title: `Unchecked error`,
+		Text: `The error returned by a function is neither checked nor explicitly
+discarded. Functions that are known to never return an error, such as
+(*bytes.Buffer).Write, are exempt.`,
+		Since: "Unreleased",
+	},
+}
+
+func init() {
+	lint.InitDocs(docs, map[string]string{"ERR": "Error handling"})
+}
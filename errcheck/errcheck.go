@@ -22,12 +22,17 @@ func (*Checker) Prefix() string { return "ERR" }
 
 func (c *Checker) Checks() []lint.Check {
 	return []lint.Check{
-		{ID: "ERR1000", FilterGenerated: false, Fn: c.CheckErrcheck},
+		{ID: "ERR1000", FilterGenerated: false, Fn: c.CheckErrcheck, Doc: docs["ERR1000"]},
 	}
 }
 
 func (c *Checker) Init(prog *lint.Program) {
 	c.funcDescs = functions.NewDescriptions(prog.SSA)
+	if prog.ImportObjectFact != nil {
+		c.funcDescs.Imported = func(fn *types.Func, s *functions.Summary) bool {
+			return prog.ImportObjectFact(fn, s)
+		}
+	}
 }
 
 func (c *Checker) CheckErrcheck(j *lint.Job) {
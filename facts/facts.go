@@ -0,0 +1,135 @@
+// Package facts computes and serializes facts about packages. Facts
+// summarize the parts of a package that its dependents need to know
+// about when they can't inspect the package's syntax, such as which
+// functions are pure or which objects have been deprecated.
+package facts // import "honnef.co/go/tools/facts"
+
+import (
+	"encoding/gob"
+	"go/types"
+	"reflect"
+
+	"golang.org/x/tools/go/types/objectpath"
+	"honnef.co/go/tools/deprecated"
+	"honnef.co/go/tools/functions"
+	"honnef.co/go/tools/lint"
+)
+
+func init() {
+	for _, fact := range Types() {
+		gob.Register(fact)
+	}
+}
+
+// Types returns a value of each type of fact that is produced by
+// Compute.
+func Types() []lint.Fact {
+	return []lint.Fact{new(functions.Summary), new(deprecated.IsDeprecated)}
+}
+
+// Compute computes facts about the objects declared in pkg and passes
+// them to export. Facts about dependencies are looked up via
+// prog.ImportObjectFact.
+func Compute(prog *lint.Program, pkg *lint.Pkg, export func(obj types.Object, fact lint.Fact)) {
+	descs := functions.NewDescriptions(prog.SSA)
+	if prog.ImportObjectFact != nil {
+		descs.Imported = func(fn *types.Func, s *functions.Summary) bool {
+			return prog.ImportObjectFact(fn, s)
+		}
+	}
+	for _, fn := range prog.InitialFunctions {
+		obj, ok := fn.Object().(*types.Func)
+		if !ok || obj.Pkg() != pkg.Types {
+			continue
+		}
+		d := descs.Get(fn)
+		s := functions.Summary{
+			Pure:     d.Pure,
+			Stub:     d.Stub,
+			Infinite: d.Infinite,
+			NilError: d.NilError,
+		}
+		if s != (functions.Summary{}) {
+			export(obj, &s)
+		}
+	}
+
+	for obj, msg := range deprecated.Objects(pkg.Syntax, pkg.TypesInfo) {
+		if obj.Pkg() != pkg.Types {
+			continue
+		}
+		export(obj, &deprecated.IsDeprecated{Msg: msg})
+	}
+}
+
+// Serialized is a fact in a form that can be encoded with
+// encoding/gob. The object is identified by its path relative to its
+// package.
+type Serialized struct {
+	Path string
+	Fact lint.Fact
+}
+
+// Serialize returns the serializable form of a fact about obj. It
+// returns false if obj cannot be addressed from outside its package,
+// in which case the fact is of no use to dependents anyway.
+func Serialize(obj types.Object, fact lint.Fact) (Serialized, bool) {
+	path, err := objectpath.For(obj)
+	if err != nil {
+		return Serialized{}, false
+	}
+	return Serialized{Path: string(path), Fact: fact}, true
+}
+
+// Object returns the object in pkg that the fact is about.
+func (s Serialized) Object(pkg *types.Package) (types.Object, error) {
+	return objectpath.Object(pkg, objectpath.Path(s.Path))
+}
+
+// A Store holds facts about objects, typically about objects in
+// dependencies that were loaded from export data.
+type Store struct {
+	m map[types.Object][]lint.Fact
+}
+
+func NewStore() *Store {
+	return &Store{m: map[types.Object][]lint.Fact{}}
+}
+
+// Add adds the serialized facts about objects in pkg to the store.
+// Facts about objects that no longer exist are skipped.
+func (s *Store) Add(pkg *types.Package, facts []Serialized) {
+	for _, f := range facts {
+		obj, err := f.Object(pkg)
+		if err != nil {
+			continue
+		}
+		s.Export(obj, f.Fact)
+	}
+}
+
+// Export records fact about obj, replacing any previous fact of the
+// same type.
+func (s *Store) Export(obj types.Object, fact lint.Fact) {
+	facts := s.m[obj]
+	for i, f := range facts {
+		if reflect.TypeOf(f) == reflect.TypeOf(fact) {
+			facts[i] = fact
+			return
+		}
+	}
+	s.m[obj] = append(facts, fact)
+}
+
+// Import copies the fact about obj of the same type as fact into
+// fact, which must be a pointer. It reports whether such a fact
+// exists. Its signature matches lint.Program.ImportObjectFact.
+func (s *Store) Import(obj types.Object, fact lint.Fact) bool {
+	for _, f := range s.m[obj] {
+		if reflect.TypeOf(f) == reflect.TypeOf(fact) {
+			reflect.ValueOf(fact).Elem().Set(reflect.ValueOf(f).Elem())
+			return true
+		}
+	}
+	return false
+}
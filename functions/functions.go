@@ -1,6 +1,7 @@
 package functions
 
 import (
+	"fmt"
 	"go/types"
 	"sync"
 
@@ -61,6 +62,23 @@ type Description struct {
 	ConcreteReturnTypes []*types.Tuple
 }
 
+// Summary is the part of a function's description that is of
+// interest to the function's callers. It can be computed while
+// analysing the function's package and shared with packages that
+// don't have access to the function's body.
+type Summary struct {
+	Pure     bool
+	Stub     bool
+	Infinite bool
+	NilError bool
+}
+
+func (*Summary) AFact() {}
+
+func (s *Summary) String() string {
+	return fmt.Sprintf("pure=%t stub=%t infinite=%t nilerror=%t", s.Pure, s.Stub, s.Infinite, s.NilError)
+}
+
 type descriptionEntry struct {
 	ready  chan struct{}
 	result Description
@@ -68,8 +86,13 @@ type descriptionEntry struct {
 
 type Descriptions struct {
 	CallGraph *callgraph.Graph
-	mu        sync.Mutex
-	cache     map[*ssa.Function]*descriptionEntry
+	// Imported, if set, is consulted for functions that have no
+	// body, such as functions in packages that were loaded without
+	// syntax. It reports whether a summary for fn is known.
+	Imported func(fn *types.Func, s *Summary) bool
+
+	mu    sync.Mutex
+	cache map[*ssa.Function]*descriptionEntry
 }
 
 func NewDescriptions(prog *ssa.Program) *Descriptions {
@@ -89,7 +112,13 @@ func (d *Descriptions) Get(fn *ssa.Function) Description {
 		d.cache[fn] = fd
 		d.mu.Unlock()
 
-		{
+		if s, ok := d.imported(fn); ok {
+			fd.result = stdlibDescs[fn.RelString(nil)]
+			fd.result.Pure = fd.result.Pure || s.Pure
+			fd.result.Stub = s.Stub
+			fd.result.Infinite = fd.result.Infinite || s.Infinite
+			fd.result.NilError = fd.result.NilError || s.NilError
+		} else {
 			fd.result = stdlibDescs[fn.RelString(nil)]
 			fd.result.Pure = fd.result.Pure || d.IsPure(fn)
 			fd.result.Stub = fd.result.Stub || d.IsStub(fn)
@@ -108,6 +137,18 @@ func (d *Descriptions) Get(fn *ssa.Function) Description {
 	return fd.result
 }
 
+func (d *Descriptions) imported(fn *ssa.Function) (Summary, bool) {
+	var s Summary
+	if fn.Blocks != nil || d.Imported == nil {
+		return s, false
+	}
+	obj, ok := fn.Object().(*types.Func)
+	if !ok {
+		return s, false
+	}
+	return s, d.Imported(obj, &s)
+}
+
 func IsNilError(fn *ssa.Function) bool {
 	// TODO(dh): This is very simplistic, as we only look for constant
 	// nil returns. A more advanced approach would work transitively.
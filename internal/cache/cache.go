@@ -0,0 +1,118 @@
+// Package cache implements a simple on-disk cache of gob-encoded
+// values, addressed by content hashes.
+package cache // import "honnef.co/go/tools/internal/cache"
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// A Key identifies an entry in the cache.
+type Key [sha256.Size]byte
+
+func (k Key) String() string { return hex.EncodeToString(k[:]) }
+
+// A Hash computes a Key.
+type Hash struct {
+	h hash.Hash
+}
+
+func NewHash() *Hash { return &Hash{h: sha256.New()} }
+
+func (h *Hash) Write(b []byte) (int, error) { return h.h.Write(b) }
+
+// Printf writes formatted data to the hash.
+func (h *Hash) Printf(format string, args ...interface{}) {
+	fmt.Fprintf(h.h, format, args...)
+}
+
+func (h *Hash) Sum() Key {
+	var k Key
+	h.h.Sum(k[:0])
+	return k
+}
+
+// Cache is a directory of cache entries.
+type Cache struct {
+	dir string
+}
+
+// Open opens the cache in dir, creating the directory if necessary.
+func Open(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, err
+	}
+	return &Cache{dir: dir}, nil
+}
+
+// DefaultDir returns the default location of the cache. It can be
+// overridden with the STATICCHECK_CACHE environment variable.
+func DefaultDir() (string, error) {
+	if dir := os.Getenv("STATICCHECK_CACHE"); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "staticcheck"), nil
+}
+
+// Default opens the cache in the default location.
+func Default() (*Cache, error) {
+	dir, err := DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+	return Open(dir)
+}
+
+func (c *Cache) path(k Key) string {
+	s := k.String()
+	return filepath.Join(c.dir, s[:2], s)
+}
+
+// Get decodes the entry for k into v. It reports whether the entry
+// existed and could be decoded.
+func (c *Cache) Get(k Key, v interface{}) bool {
+	b, err := ioutil.ReadFile(c.path(k))
+	if err != nil {
+		return false
+	}
+	return gob.NewDecoder(bytes.NewReader(b)).Decode(v) == nil
+}
+
+// Put stores v as the entry for k. The entry is written atomically,
+// so that concurrent processes never observe partial entries.
+func (c *Cache) Put(k Key, v interface{}) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return err
+	}
+	p := c.path(k)
+	if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(p), "tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(buf.Bytes())
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), p)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := func(s string) Key {
+		h := NewHash()
+		h.Printf("%s", s)
+		return h.Sum()
+	}
+	if key("a") != key("a") {
+		t.Error("hashing the same data produced different keys")
+	}
+	if key("a") == key("b") {
+		t.Error("hashing different data produced the same key")
+	}
+
+	type entry struct {
+		Names []string
+		N     int
+	}
+	var got entry
+	if c.Get(key("a"), &got) {
+		t.Fatal("got an entry from an empty cache")
+	}
+	want := entry{[]string{"x", "y"}, 3}
+	if err := c.Put(key("a"), want); err != nil {
+		t.Fatal(err)
+	}
+	if !c.Get(key("a"), &got) {
+		t.Fatal("entry wasn't stored")
+	}
+	if got.N != want.N || len(got.Names) != 2 || got.Names[1] != "y" {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if c.Get(key("b"), &got) {
+		t.Error("got an entry for a different key")
+	}
+
+	// Entries that can't be decoded are misses.
+	var wrong struct{ N string }
+	if c.Get(key("a"), &wrong) {
+		t.Error("decoded an entry into the wrong type")
+	}
+}
@@ -0,0 +1,64 @@
+package lint
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+)
+
+// ErrConflictingEdits is returned by ApplyEdits if two edits overlap.
+var ErrConflictingEdits = errors.New("conflicting edits")
+
+// ApplyEdits applies edits to src and returns the modified source.
+// All edits must refer to the same file. Identical edits are only
+// applied once, regardless of their order; overlapping edits are
+// rejected with ErrConflictingEdits.
+func ApplyEdits(src []byte, edits []Edit) ([]byte, error) {
+	edits = append([]Edit(nil), edits...)
+	sort.Slice(edits, func(i, j int) bool {
+		ei, ej := edits[i], edits[j]
+		if ei.Start.Offset != ej.Start.Offset {
+			return ei.Start.Offset < ej.Start.Offset
+		}
+		if ei.End.Offset != ej.End.Offset {
+			return ei.End.Offset < ej.End.Offset
+		}
+		return ei.NewText < ej.NewText
+	})
+
+	var buf bytes.Buffer
+	off := 0
+	var prev *Edit
+	for i := range edits {
+		e := &edits[i]
+		if prev != nil && e.Start.Offset == prev.Start.Offset && e.End.Offset == prev.End.Offset && e.NewText == prev.NewText {
+			continue
+		}
+		if e.Start.Offset < off || e.End.Offset < e.Start.Offset || e.End.Offset > len(src) {
+			return nil, ErrConflictingEdits
+		}
+		if prev != nil && e.Start.Offset == e.End.Offset && prev.Start.Offset == prev.End.Offset && e.Start.Offset == prev.Start.Offset {
+			// two insertions at the same offset have no well-defined order
+			return nil, ErrConflictingEdits
+		}
+		buf.Write(src[off:e.Start.Offset])
+		buf.WriteString(e.NewText)
+		off = e.End.Offset
+		prev = e
+	}
+	buf.Write(src[off:])
+	return buf.Bytes(), nil
+}
+
+// Overlaps reports whether the two edits modify overlapping ranges of
+// the same file. Identical edits do not overlap.
+func (e Edit) Overlaps(o Edit) bool {
+	if e == o || e.Start.Filename != o.Start.Filename {
+		return false
+	}
+	if e.Start.Offset == e.End.Offset && o.Start.Offset == o.End.Offset {
+		// two insertions at the same offset have no well-defined order
+		return e.Start.Offset == o.Start.Offset
+	}
+	return e.Start.Offset < o.End.Offset && o.Start.Offset < e.End.Offset
+}
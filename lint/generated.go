@@ -4,6 +4,10 @@ import (
 	"bufio"
 	"bytes"
 	"io"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
 )
 
 var (
@@ -13,7 +17,9 @@ var (
 	crnl   = []byte("\r\n")
 )
 
-func isGenerated(r io.Reader) bool {
+// isGenerated reports whether the file read from r has the standard
+// header of generated code, or a line matching any of headers.
+func isGenerated(r io.Reader, headers []*regexp.Regexp) bool {
 	br := bufio.NewReader(r)
 	for {
 		s, err := br.ReadBytes('\n')
@@ -25,9 +31,59 @@ func isGenerated(r io.Reader) bool {
 		if bytes.HasPrefix(s, prefix) && bytes.HasSuffix(s, suffix) {
 			return true
 		}
+		for _, header := range headers {
+			if header.Match(s) {
+				return true
+			}
+		}
 		if err == io.EOF {
 			break
 		}
 	}
 	return false
 }
+
+// generatedConfig is the compiled form of a package's configuration
+// of generated files.
+type generatedConfig struct {
+	files   []string
+	headers []*regexp.Regexp
+}
+
+func newGeneratedConfig(files, headers []string) *generatedConfig {
+	gc := &generatedConfig{files: files}
+	for _, header := range headers {
+		// config.Load rejects invalid expressions.
+		if re, err := regexp.Compile(header); err == nil {
+			gc.headers = append(gc.headers, re)
+		}
+	}
+	return gc
+}
+
+// matchPath reports whether the file filename, in the package with
+// import path pkgpath, matches any of the configured patterns.
+func (gc *generatedConfig) matchPath(pkgpath, filename string) bool {
+	pkgpath = strings.TrimSuffix(pkgpath, "_test")
+	base := filepath.Base(filename)
+	name := pkgpath + "/" + base
+	for _, pattern := range gc.files {
+		if strings.HasSuffix(pattern, "/...") {
+			tree := strings.TrimSuffix(pattern, "/...")
+			if pkgpath == tree || strings.HasPrefix(pkgpath, tree+"/") {
+				return true
+			}
+			continue
+		}
+		if !strings.Contains(pattern, "/") {
+			if m, _ := path.Match(pattern, base); m {
+				return true
+			}
+			continue
+		}
+		if m, _ := path.Match(pattern, name); m {
+			return true
+		}
+	}
+	return false
+}
@@ -2,13 +2,18 @@
 package lint // import "honnef.co/go/tools/lint"
 
 import (
+	"context"
 	"fmt"
 	"go/ast"
+	"go/build"
 	"go/token"
 	"go/types"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime/debug"
 	"sort"
 	"strings"
 	"sync"
@@ -24,61 +29,121 @@ import (
 type Job struct {
 	Program *Program
 
+	ctx      context.Context
 	checker  string
 	check    Check
 	problems []Problem
+	// wholeProgram is set if the checker is a whole-program
+	// checker, whose results depend on all packages at once.
+	wholeProgram bool
 
 	duration time.Duration
+	skipped  int
 }
 
 type Ignore interface {
 	Match(p Problem) bool
 }
 
+// A Directive is a linter directive in the source code that
+// suppresses problems.
+type Directive struct {
+	// Command is one of "ignore", "ignore-start" and "file-ignore".
+	Command  string
+	Position token.Position
+	Checks   []string
+	Reason   string
+	// Until is the day after which the directive expires. It is the
+	// zero time for directives that don't expire.
+	Until time.Time
+	// Matched reports whether the directive suppressed any problems.
+	Matched bool
+}
+
+// Expired reports whether the directive has expired at time now.
+// Directives expire at the end of the day Until, in UTC. Expired
+// directives don't suppress problems anymore.
+func (d *Directive) Expired(now time.Time) bool {
+	return !d.Until.IsZero() && !now.UTC().Before(d.Until.AddDate(0, 0, 1))
+}
+
+func matchChecks(checks []string, check string) bool {
+	for _, c := range checks {
+		if m, _ := filepath.Match(c, check); m {
+			return true
+		}
+	}
+	return false
+}
+
 type LineIgnore struct {
-	File    string
-	Line    int
-	Checks  []string
-	matched bool
-	pos     token.Pos
+	File      string
+	Line      int
+	Checks    []string
+	directive *Directive
 }
 
 func (li *LineIgnore) Match(p Problem) bool {
 	if p.Position.Filename != li.File || p.Position.Line != li.Line {
 		return false
 	}
-	for _, c := range li.Checks {
-		if m, _ := filepath.Match(c, p.Check); m {
-			li.matched = true
-			return true
-		}
+	if !matchChecks(li.Checks, p.Check) {
+		return false
 	}
-	return false
+	if li.directive != nil {
+		li.directive.Matched = true
+	}
+	return true
 }
 
 func (li *LineIgnore) String() string {
 	matched := "not matched"
-	if li.matched {
+	if li.directive != nil && li.directive.Matched {
 		matched = "matched"
 	}
 	return fmt.Sprintf("%s:%d %s (%s)", li.File, li.Line, strings.Join(li.Checks, ", "), matched)
 }
 
+// A RangeIgnore ignores problems on the lines between an
+// ignore-start and an ignore-end directive, inclusively.
+type RangeIgnore struct {
+	File      string
+	Start     int
+	End       int
+	Checks    []string
+	directive *Directive
+}
+
+func (ri *RangeIgnore) Match(p Problem) bool {
+	if p.Position.Filename != ri.File || p.Position.Line < ri.Start || p.Position.Line > ri.End {
+		return false
+	}
+	if !matchChecks(ri.Checks, p.Check) {
+		return false
+	}
+	if ri.directive != nil {
+		ri.directive.Matched = true
+	}
+	return true
+}
+
 type FileIgnore struct {
-	File   string
-	Checks []string
+	File      string
+	Checks    []string
+	directive *Directive
 }
 
 func (fi *FileIgnore) Match(p Problem) bool {
 	if p.Position.Filename != fi.File {
 		return false
 	}
-	for _, c := range fi.Checks {
-		if m, _ := filepath.Match(c, p.Check); m {
-			return true
-		}
+	if !matchChecks(fi.Checks, p.Check) {
+		return false
 	}
-	return false
+	if fi.directive != nil {
+		fi.directive.Matched = true
+	}
+	return true
 }
 
 type GlobIgnore struct {
@@ -97,12 +162,7 @@ func (gi *GlobIgnore) Match(p Problem) bool {
 			return false
 		}
 	}
-	for _, c := range gi.Checks {
-		if m, _ := filepath.Match(c, p.Check); m {
-			return true
-		}
-	}
-	return false
+	return matchChecks(gi.Checks, p.Check)
 }
 
 type Program struct {
@@ -113,37 +173,268 @@ type Program struct {
 	AllFunctions     []*ssa.Function
 	Files            []*ast.File
 	GoVersion        int
+	// ImportObjectFact looks up facts about objects in packages that
+	// were loaded without syntax. It is nil if the entire program
+	// was loaded from source.
+	ImportObjectFact func(obj types.Object, fact Fact) bool
 
 	tokenFileMap map[*token.File]*ast.File
 	astFileMap   map[*ast.File]*Pkg
 	packagesMap  map[string]*packages.Package
 
-	genMu        sync.RWMutex
+	// genMu and generatedMap are shared with restricted views of
+	// the program, hence the pointer.
+	genMu        *sync.RWMutex
 	generatedMap map[string]bool
 }
 
+// defaultGoVersion returns the Go version to target in packages that
+// don't declare one.
+func (l *Linter) defaultGoVersion() int {
+	if l.GoVersion != 0 {
+		return l.GoVersion
+	}
+	tags := build.Default.ReleaseTags
+	v, _ := config.ParseGoVersion(tags[len(tags)-1][len("go"):])
+	return v
+}
+
+// goVersion returns the Go version to target in the package in dir,
+// which is configured by cfg.
+func (l *Linter) goVersion(dir string, cfg config.Config) int {
+	if l.GoVersion != 0 {
+		return l.GoVersion
+	}
+	if cfg.GoVersion != "" {
+		if v, err := config.ParseGoVersion(cfg.GoVersion); err == nil {
+			return v
+		}
+	}
+	if v, ok := config.ModuleGoVersion(dir); ok {
+		return v
+	}
+	return l.defaultGoVersion()
+}
+
 func (prog *Program) Fset() *token.FileSet {
 	return prog.InitialPackages[0].Fset
 }
 
 type Func func(*Job)
 
+// A Fact is a summary of an object, computed while checking the
+// object's package and consumed while checking packages that depend
+// on it but don't have access to its syntax. It is compatible with
+// golang.org/x/tools/go/analysis.Fact.
+type Fact interface {
+	AFact()
+}
+
 type Severity uint8
 
 const (
 	Error Severity = iota
 	Warning
 	Ignored
+	Info
+	Hint
 )
 
+// severities maps the names of severities, as used in configuration
+// files, to severities. The special severity "off" disables checks.
+var severities = map[string]Severity{
+	"error":   Error,
+	"warning": Warning,
+	"info":    Info,
+	"hint":    Hint,
+}
+
+// CheckSeverities resolves the severities configured for checks, in
+// the format of config.Config.Severity. Patterns are applied from the
+// least to the most specific, so that, for example, SA5000 overrides
+// SA5*, which overrides SA*, which overrides all. Checks without a
+// configured severity are absent from the result.
+func CheckSeverities(allChecks []string, config map[string]string) map[string]string {
+	patterns := make([]string, 0, len(config))
+	for pattern := range config {
+		patterns = append(patterns, pattern)
+	}
+	out := map[string]string{}
+	for check, pattern := range resolveChecks(allChecks, patterns) {
+		out[check] = config[pattern]
+	}
+	return out
+}
+
+// configProblems turns errors in the configuration of pkgs into
+// problems, and warns about check patterns that don't match any
+// check.
+func configProblems(pkgs []*Pkg, errs map[*Pkg]error, checkers []Checker, allChecks []string) []Problem {
+	var out []Problem
+	for _, pkg := range pkgs {
+		err, ok := errs[pkg]
+		if !ok {
+			continue
+		}
+		if cerr, ok := err.(*config.Error); ok {
+			for _, p := range cerr.Problems {
+				out = append(out, Problem{
+					Position: p.Position,
+					Text:     p.Text,
+					Checker:  "config",
+					Package:  pkg,
+					Severity: Error,
+				})
+			}
+		} else {
+			p := Problem{
+				Text:     fmt.Sprintf("couldn't load configuration: %s", err),
+				Checker:  "config",
+				Package:  pkg,
+				Severity: Error,
+			}
+			if len(pkg.GoFiles) > 0 {
+				p.Position.Filename = pkg.GoFiles[0]
+			}
+			out = append(out, p)
+		}
+	}
+
+	// Patterns that don't belong to any of our checkers may be meant
+	// for other tools sharing the configuration, such as gosimple's
+	// configuration disabling staticcheck's checks.
+	prefixes := map[string]bool{}
+	for _, c := range checkers {
+		if c.Prefix() != "" {
+			prefixes[c.Prefix()] = true
+		}
+	}
+	known := map[string]bool{}
+	for _, check := range allChecks {
+		known[check] = true
+	}
+	for _, pkg := range pkgs {
+		for _, cp := range pkg.Config.CheckPatterns {
+			i := strings.IndexFunc(cp.Pattern, func(r rune) bool { return !unicode.IsLetter(r) })
+			if i < 0 {
+				i = len(cp.Pattern)
+			}
+			if !prefixes[strings.ToUpper(cp.Pattern[:i])] {
+				continue
+			}
+			matched := false
+			for check, ok := range FilterChecks(allChecks, []string{cp.Pattern}) {
+				if ok && known[check] {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				out = append(out, Problem{
+					Position: cp.Position,
+					Text:     fmt.Sprintf("%q doesn't match any check", cp.Pattern),
+					Checker:  "config",
+					Package:  pkg,
+					Severity: Warning,
+				})
+			}
+		}
+	}
+
+	// Like check patterns, sections of unknown checkers may be meant
+	// for other tools.
+	configurable := map[string]ConfigurableChecker{}
+	for _, c := range checkers {
+		if cc, ok := c.(ConfigurableChecker); ok {
+			configurable[c.Name()] = cc
+		}
+	}
+	for _, pkg := range pkgs {
+		for _, s := range pkg.Config.CheckerSections {
+			c, ok := configurable[s.Checker]
+			if !ok {
+				continue
+			}
+			for _, p := range s.Validate(c.NewConfig()) {
+				out = append(out, Problem{
+					Position: p.Position,
+					Text:     p.Text,
+					Checker:  "config",
+					Package:  pkg,
+					Severity: Error,
+				})
+			}
+		}
+	}
+	return out
+}
+
+// resolveChecks maps each check to the most specific of patterns
+// that matches it, in the manner of CheckSeverities.
+func resolveChecks(allChecks []string, patterns []string) map[string]string {
+	specificity := func(pattern string) int {
+		switch {
+		case pattern == "*" || pattern == "all":
+			return 0
+		case strings.HasSuffix(pattern, "*"):
+			return len(pattern)
+		default:
+			return math.MaxInt32
+		}
+	}
+	patterns = append([]string(nil), patterns...)
+	sort.Slice(patterns, func(i, j int) bool {
+		si, sj := specificity(patterns[i]), specificity(patterns[j])
+		if si != sj {
+			return si < sj
+		}
+		return patterns[i] < patterns[j]
+	})
+
+	out := map[string]string{}
+	for _, pattern := range patterns {
+		for check, ok := range FilterChecks(allChecks, []string{pattern}) {
+			if ok {
+				out[check] = pattern
+			}
+		}
+	}
+	return out
+}
+
 // Problem represents a problem in some source code.
 type Problem struct {
 	Position token.Position // position in source file
+	End      token.Position // end of the problem's source range, if known
 	Text     string         // the prose that describes the problem
 	Check    string
 	Checker  string
 	Package  *Pkg
 	Severity Severity
+	// Edits is an optional, machine-applicable fix for the problem.
+	// All edits have to be applied together.
+	Edits []Edit
+	// Related are other positions that are relevant to the problem.
+	Related []Related
+	// Suppression is the linter directive that suppressed the
+	// problem, if its severity is Ignored because of one.
+	Suppression *Directive
+}
+
+// Related describes a position that is related to a problem, such as
+// the place where a value is overwritten.
+type Related struct {
+	Position token.Position
+	Message  string
+}
+
+// An Edit describes the replacement of a range of source code with
+// new text. Positions refer to the physical file and are not
+// adjusted by //line directives.
+type Edit struct {
+	Start   token.Position
+	End     token.Position
+	NewText string
 }
 
 func (p *Problem) String() string {
@@ -160,49 +451,201 @@ type Checker interface {
 	Checks() []Check
 }
 
+// A CacheKeyer is a Checker whose problems depend on options other
+// than the checks it runs, such as whether to check generated code.
+// Results are only reused from the cache if the keys match.
+type CacheKeyer interface {
+	Checker
+	// CacheKey returns a string identifying the checker's options.
+	CacheKey() string
+}
+
+// A WholeProgramChecker is a Checker whose problems in one package
+// may depend on the other packages that are linted along with it,
+// such as uses of an identifier from another package.
+type WholeProgramChecker interface {
+	Checker
+	// WholeProgram reports whether the checker currently looks at
+	// the whole program.
+	WholeProgram() bool
+}
+
+// IsWholeProgram reports whether c is a WholeProgramChecker looking
+// at the whole program.
+func IsWholeProgram(c Checker) bool {
+	wc, ok := c.(WholeProgramChecker)
+	return ok && wc.WholeProgram()
+}
+
+// A ConfigurableChecker is a Checker with its own section of the
+// configuration, [checker.<name>], where name is the checker's name.
+// Checks decode the section with the Section method of their
+// package's Config.
+type ConfigurableChecker interface {
+	Checker
+	// NewConfig returns a pointer to a new value of the checker's
+	// configuration type, which is used to validate sections.
+	NewConfig() interface{}
+}
+
 type Check struct {
 	Fn              Func
 	ID              string
 	FilterGenerated bool
+	Doc             *Documentation
+}
+
+// Documentation describes a check to users.
+type Documentation struct {
+	// Title is a one-line summary of the problems the check finds.
+	Title string
+	// Text is an optional, longer explanation, possibly with examples.
+	Text string
+	// Since is the first release that contained the check.
+	Since string
+	// NonDefault is set for checks that aren't enabled by default.
+	NonDefault bool
+	// Category groups related checks.
+	Category string
+}
+
+// InitDocs completes the documentation of a checker's checks. Each
+// check is put in the category of the longest prefix of its ID in
+// categories, and NonDefault is derived from the default
+// configuration.
+func InitDocs(docs map[string]*Documentation, categories map[string]string) {
+	defaults := config.DefaultChecks()
+	for id, doc := range docs {
+		n := -1
+		for prefix, category := range categories {
+			if strings.HasPrefix(id, prefix) && len(prefix) > n {
+				doc.Category = category
+				n = len(prefix)
+			}
+		}
+		doc.NonDefault = !FilterChecks([]string{id}, defaults)[id]
+	}
+}
+
+func (doc *Documentation) String() string {
+	var b strings.Builder
+	b.WriteString(doc.Title)
+	b.WriteString("\n\n")
+	if doc.Text != "" {
+		b.WriteString(doc.Text)
+		b.WriteString("\n\n")
+	}
+	if doc.Category != "" {
+		fmt.Fprintf(&b, "Category\n    %s\n\n", doc.Category)
+	}
+	fmt.Fprintf(&b, "Available since\n    %s\n", doc.Since)
+	if doc.NonDefault {
+		b.WriteString("\nNon-default\n    This check is disabled by default.\n")
+	}
+	return b.String()
 }
 
 // A Linter lints Go source code.
 type Linter struct {
-	Checkers      []Checker
-	Ignores       []Ignore
+	Checkers []Checker
+	Ignores  []Ignore
+	// GoVersion, if non-zero, is the minor Go version to target in
+	// all packages. Otherwise, each package targets the version set
+	// in its configuration, or declared by its module's go.mod, or
+	// that of the Go toolchain.
 	GoVersion     int
 	ReturnIgnored bool
 	Config        config.Config
 
+	// ImportObjectFact, if set, provides facts about objects in
+	// dependencies that were loaded without syntax.
+	ImportObjectFact func(obj types.Object, fact Fact) bool
+	// SSA, if set, is the SSA form of the packages to lint and their
+	// dependencies, as built by an earlier call to Lint on the same
+	// packages or on packages importing them. Lint sets it to the
+	// program it uses.
+	SSA *ssa.Program
+	// Initialized is the program that the checkers were last
+	// initialized for. Initializing the checkers is expensive and
+	// modifies the program, so Lint doesn't initialize them again
+	// for the same program. Lint sets it to the program it uses.
+	Initialized *ssa.Program
+
 	MaxConcurrentJobs int
 	PrintStats        bool
+	// JobTimeout, if positive, limits the time a single check may
+	// take. Checks that exceed it are abandoned and reported as
+	// internal errors.
+	JobTimeout time.Duration
 
 	automaticIgnores []Ignore
+	directives       []*Directive
+	// now is the time at which Lint started, which directives'
+	// expiry dates are compared with.
+	now time.Time
 }
 
-func (l *Linter) ignore(p Problem) bool {
+// Directives returns the linter directives that suppress problems
+// that were found by the last call to Lint.
+func (l *Linter) Directives() []Directive {
+	out := make([]Directive, len(l.directives))
+	for i, d := range l.directives {
+		out[i] = *d
+	}
+	return out
+}
+
+// ignore reports whether p is ignored, and by which linter
+// directive, if any.
+func (l *Linter) ignore(p Problem) (bool, *Directive) {
 	ignored := false
+	var directive *Directive
 	for _, ig := range l.automaticIgnores {
+		if d := ignoreDirective(ig); d != nil && d.Expired(l.now) {
+			continue
+		}
 		// We cannot short-circuit these, as we want to record, for
 		// each ignore, whether it matched or not.
 		if ig.Match(p) {
 			ignored = true
+			if directive == nil {
+				directive = ignoreDirective(ig)
+			}
 		}
 	}
 	if ignored {
 		// no need to execute other ignores if we've already had a
 		// match.
-		return true
+		return true, directive
 	}
 	for _, ig := range l.Ignores {
 		// We can short-circuit here, as we aren't tracking any
 		// information.
 		if ig.Match(p) {
-			return true
+			return true, nil
 		}
 	}
 
-	return false
+	return false, nil
+}
+
+// ignoreDirective returns a copy of the directive that ig was parsed
+// from, if any.
+func ignoreDirective(ig Ignore) *Directive {
+	var d *Directive
+	switch ig := ig.(type) {
+	case *LineIgnore:
+		d = ig.directive
+	case *RangeIgnore:
+		d = ig.directive
+	case *FileIgnore:
+		d = ig.directive
+	}
+	if d == nil {
+		return nil
+	}
+	cd := *d
+	return &cd
 }
 
 func (prog *Program) File(node Positioner) *ast.File {
@@ -222,17 +665,51 @@ func parseDirective(s string) (cmd string, args []string) {
 	return fields[0], fields[1:]
 }
 
+// parseIgnore parses the arguments of the ignore, ignore-start and
+// file-ignore directives: a comma-separated list of checks, an
+// optional expiry date of the form until=YYYY-MM-DD, and the reason.
+// On failure, it returns a description of the problem.
+func parseIgnore(cmd string, args []string) (*Directive, string) {
+	if len(args) < 2 {
+		return nil, "malformed linter directive; missing the required reason field?"
+	}
+	d := &Directive{
+		Command: cmd,
+		Checks:  strings.Split(args[0], ","),
+	}
+	args = args[1:]
+	if strings.HasPrefix(args[0], "until=") {
+		until, err := time.Parse("2006-01-02", strings.TrimPrefix(args[0], "until="))
+		if err != nil {
+			return nil, fmt.Sprintf("malformed linter directive; invalid expiry date %q", strings.TrimPrefix(args[0], "until="))
+		}
+		d.Until = until
+		args = args[1:]
+	}
+	d.Reason = strings.TrimSpace(strings.Join(args, " "))
+	if d.Reason == "" {
+		return nil, "malformed linter directive; missing the required reason field?"
+	}
+	return d, ""
+}
+
 type PerfStats struct {
 	PackageLoading time.Duration
 	SSABuild       time.Duration
 	OtherInitWork  time.Duration
 	CheckerInits   map[string]time.Duration
 	Jobs           []JobStat
+	// SkippedJobs lists the checks that are disabled in all
+	// packages and thus weren't run at all.
+	SkippedJobs []string
 }
 
 type JobStat struct {
 	Job      string
 	Duration time.Duration
+	// SkippedPackages is the number of initial packages that the
+	// check is disabled in and that the job didn't look at.
+	SkippedPackages int
 }
 
 func (stats *PerfStats) Print(w io.Writer) {
@@ -252,61 +729,92 @@ func (stats *PerfStats) Print(w io.Writer) {
 	})
 	var total time.Duration
 	for _, job := range stats.Jobs {
-		fmt.Fprintf(w, "\t%s: %s\n", job.Job, job.Duration)
+		if job.SkippedPackages > 0 {
+			fmt.Fprintf(w, "\t%s: %s (skipped %d packages)\n", job.Job, job.Duration, job.SkippedPackages)
+		} else {
+			fmt.Fprintf(w, "\t%s: %s\n", job.Job, job.Duration)
+		}
 		total += job.Duration
 	}
 	fmt.Fprintf(w, "\tTotal: %s\n", total)
+
+	if len(stats.SkippedJobs) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Skipped jobs:")
+		for _, job := range stats.SkippedJobs {
+			fmt.Fprintf(w, "\t%s\n", job)
+		}
+	}
 }
 
-func (l *Linter) Lint(initial []*packages.Package, stats *PerfStats) []Problem {
+// Lint runs all checks on the initial packages. It stops early and
+// returns the context's error if ctx is cancelled. Checks that panic
+// or exceed the job timeout don't abort linting; they are reported
+// as internal errors instead.
+func (l *Linter) Lint(ctx context.Context, initial []*packages.Package, stats *PerfStats) ([]Problem, error) {
 	allPkgs := allPackages(initial)
+	l.now = time.Now().UTC()
 	t := time.Now()
-	ssaprog, _ := ssautil.Packages(allPkgs, ssa.GlobalDebug)
-	ssaprog.Build()
+	ssaprog := l.SSA
+	if ssaprog == nil {
+		ssaprog, _ = ssautil.Packages(allPkgs, ssa.GlobalDebug)
+		ssaprog.Build()
+		l.SSA = ssaprog
+	}
 	if stats != nil {
 		stats.SSABuild = time.Since(t)
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	t = time.Now()
 	pkgMap := map[*ssa.Package]*Pkg{}
 	var pkgs []*Pkg
+	configErrs := map[*Pkg]error{}
 	for _, pkg := range initial {
 		ssapkg := ssaprog.Package(pkg.Types)
 		var cfg config.Config
+		var cfgErr error
+		goVersion := l.defaultGoVersion()
 		if len(pkg.GoFiles) != 0 {
 			path := pkg.GoFiles[0]
 			dir := filepath.Dir(path)
-			var err error
 			// OPT(dh): we're rebuilding the entire config tree for
 			// each package. for example, if we check a/b/c and
 			// a/b/c/d, we'll process a, a/b, a/b/c, a, a/b, a/b/c,
 			// a/b/c/d – we should cache configs per package and only
 			// load the new levels.
-			cfg, err = config.Load(dir)
-			if err != nil {
-				// FIXME(dh): we couldn't load the config, what are we
-				// supposed to do? probably tell the user somehow
-			}
+			//
+			// Invalid configuration is reported after linting.
+			cfg, cfgErr = config.LoadPackage(dir, pkg.PkgPath)
 			cfg = cfg.Merge(l.Config)
+			goVersion = l.goVersion(dir, cfg)
 		}
 
 		pkg := &Pkg{
-			SSA:     ssapkg,
-			Package: pkg,
-			Config:  cfg,
+			SSA:       ssapkg,
+			Package:   pkg,
+			Config:    cfg,
+			GoVersion: goVersion,
+		}
+		if cfgErr != nil {
+			configErrs[pkg] = cfgErr
 		}
 		pkgMap[ssapkg] = pkg
 		pkgs = append(pkgs, pkg)
 	}
 
 	prog := &Program{
-		SSA:             ssaprog,
-		InitialPackages: pkgs,
-		AllPackages:     allPkgs,
-		GoVersion:       l.GoVersion,
-		tokenFileMap:    map[*token.File]*ast.File{},
-		astFileMap:      map[*ast.File]*Pkg{},
-		generatedMap:    map[string]bool{},
+		SSA:              ssaprog,
+		InitialPackages:  pkgs,
+		AllPackages:      allPkgs,
+		GoVersion:        l.defaultGoVersion(),
+		ImportObjectFact: l.ImportObjectFact,
+		tokenFileMap:     map[*token.File]*ast.File{},
+		astFileMap:       map[*ast.File]*Pkg{},
+		genMu:            &sync.RWMutex{},
+		generatedMap:     map[string]bool{},
 	}
 	prog.packagesMap = map[string]*packages.Package{}
 	for _, pkg := range allPkgs {
@@ -344,6 +852,17 @@ func (l *Linter) Lint(initial []*packages.Package, stats *PerfStats) []Problem {
 
 	var out []Problem
 	l.automaticIgnores = nil
+	l.directives = nil
+	directiveProblem := func(pos token.Pos, text string) {
+		// FIXME(dh): this causes duplicated warnings when using megacheck
+		out = append(out, Problem{
+			Position: prog.DisplayPosition(pos),
+			Text:     text,
+			Check:    "",
+			Checker:  "lint",
+			Package:  nil,
+		})
+	}
 	for _, pkg := range initial {
 		for _, f := range pkg.Syntax {
 			cm := ast.NewCommentMap(pkg.Fset, f, f.Comments)
@@ -354,45 +873,83 @@ func (l *Linter) Lint(initial []*packages.Package, stats *PerfStats) []Problem {
 							continue
 						}
 						cmd, args := parseDirective(c.Text)
-						switch cmd {
-						case "ignore", "file-ignore":
-							if len(args) < 2 {
-								// FIXME(dh): this causes duplicated warnings when using megacheck
-								p := Problem{
-									Position: prog.DisplayPosition(c.Pos()),
-									Text:     "malformed linter directive; missing the required reason field?",
-									Check:    "",
-									Checker:  "lint",
-									Package:  nil,
-								}
-								out = append(out, p)
-								continue
-							}
-						default:
-							// unknown directive, ignore
+						if cmd != "ignore" && cmd != "file-ignore" {
+							// ranges are handled below, unknown
+							// directives are ignored
 							continue
 						}
-						checks := strings.Split(args[0], ",")
+						d, msg := parseIgnore(cmd, args)
+						if d == nil {
+							directiveProblem(c.Pos(), msg)
+							continue
+						}
+						d.Position = prog.DisplayPosition(c.Pos())
 						pos := prog.DisplayPosition(node.Pos())
 						var ig Ignore
 						switch cmd {
 						case "ignore":
 							ig = &LineIgnore{
-								File:   pos.Filename,
-								Line:   pos.Line,
-								Checks: checks,
-								pos:    c.Pos(),
+								File:      pos.Filename,
+								Line:      pos.Line,
+								Checks:    d.Checks,
+								directive: d,
 							}
 						case "file-ignore":
 							ig = &FileIgnore{
-								File:   pos.Filename,
-								Checks: checks,
+								File:      pos.Filename,
+								Checks:    d.Checks,
+								directive: d,
 							}
 						}
 						l.automaticIgnores = append(l.automaticIgnores, ig)
+						l.directives = append(l.directives, d)
+					}
+				}
+			}
+
+			// Ranges depend on the order of directives, which the
+			// comment map doesn't preserve.
+			var open []*RangeIgnore
+			for _, cg := range f.Comments {
+				for _, c := range cg.List {
+					cmd, args := parseDirective(c.Text)
+					switch cmd {
+					case "ignore-start":
+						d, msg := parseIgnore(cmd, args)
+						if d == nil {
+							directiveProblem(c.Pos(), msg)
+							continue
+						}
+						d.Position = prog.DisplayPosition(c.Pos())
+						ig := &RangeIgnore{
+							File:      d.Position.Filename,
+							Start:     d.Position.Line,
+							Checks:    d.Checks,
+							directive: d,
+						}
+						open = append(open, ig)
+						l.automaticIgnores = append(l.automaticIgnores, ig)
+						l.directives = append(l.directives, d)
+					case "ignore-end":
+						if len(open) == 0 {
+							directiveProblem(c.Pos(), "ignore-end directive without matching ignore-start")
+							continue
+						}
+						open[len(open)-1].End = prog.DisplayPosition(c.Pos()).Line
+						open = open[:len(open)-1]
 					}
 				}
 			}
+			for _, ig := range open {
+				// Ignore the remainder of the file, but let the user
+				// know.
+				ig.End = math.MaxInt32
+				out = append(out, Problem{
+					Position: ig.directive.Position,
+					Text:     "ignore-start directive without matching ignore-end",
+					Checker:  "lint",
+				})
+			}
 		}
 	}
 
@@ -417,25 +974,82 @@ func (l *Linter) Lint(initial []*packages.Package, stats *PerfStats) []Problem {
 		stats.OtherInitWork = time.Since(t)
 	}
 
-	for _, checker := range l.Checkers {
-		t := time.Now()
-		checker.Init(prog)
-		if stats != nil {
-			stats.CheckerInits[checker.Name()] = time.Since(t)
+	if l.Initialized != ssaprog {
+		for _, checker := range l.Checkers {
+			t := time.Now()
+			checker.Init(prog)
+			if stats != nil {
+				stats.CheckerInits[checker.Name()] = time.Since(t)
+			}
 		}
+		l.Initialized = ssaprog
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	var jobs []*Job
 	var allChecks []string
-
 	for _, checker := range l.Checkers {
-		checks := checker.Checks()
-		for _, check := range checks {
+		for _, check := range checker.Checks() {
 			allChecks = append(allChecks, check.ID)
+		}
+	}
+	allowedChecks := map[*Pkg]map[string]bool{}
+	checkSeverities := map[*Pkg]map[string]string{}
+	checkConfig := func(pkg *Pkg) {
+		allowed := FilterChecks(allChecks, pkg.Config.Checks)
+		sevs := CheckSeverities(allChecks, pkg.Config.Severity)
+		for check, sev := range sevs {
+			if sev == "off" {
+				allowed[check] = false
+			}
+		}
+		allowedChecks[pkg] = allowed
+		checkSeverities[pkg] = sevs
+
+		pkg.generated = newGeneratedConfig(pkg.Config.GeneratedFiles, pkg.Config.GeneratedHeaders)
+		patterns := make([]string, 0, len(pkg.Config.FilterGenerated))
+		for pattern := range pkg.Config.FilterGenerated {
+			patterns = append(patterns, pattern)
+		}
+		pkg.filterGenerated = map[string]bool{}
+		for check, pattern := range resolveChecks(allChecks, patterns) {
+			pkg.filterGenerated[check] = pkg.Config.FilterGenerated[pattern]
+		}
+	}
+	for _, pkg := range pkgs {
+		checkConfig(pkg)
+	}
+
+	// Only run checks in the packages they are enabled in. Checks
+	// that are enabled everywhere see the whole program, the others
+	// a view of it that is restricted to the packages they are
+	// enabled in.
+	var jobs []*Job
+	for _, checker := range l.Checkers {
+		for _, check := range checker.Checks() {
+			enabled := map[*Pkg]bool{}
+			for _, pkg := range pkgs {
+				if allowedChecks[pkg][check.ID] {
+					enabled[pkg] = true
+				}
+			}
+			if len(enabled) == 0 {
+				if stats != nil {
+					stats.SkippedJobs = append(stats.SkippedJobs, check.ID)
+				}
+				continue
+			}
 			j := &Job{
-				Program: prog,
-				checker: checker.Name(),
-				check:   check,
+				Program:      prog,
+				ctx:          ctx,
+				checker:      checker.Name(),
+				check:        check,
+				wholeProgram: IsWholeProgram(checker),
+			}
+			if len(enabled) < len(pkgs) {
+				j.Program = prog.restrict(enabled)
+				j.skipped = len(pkgs) - len(enabled)
 			}
 			jobs = append(jobs, j)
 		}
@@ -452,59 +1066,81 @@ func (l *Linter) Lint(initial []*packages.Package, stats *PerfStats) []Problem {
 		wg.Add(1)
 		go func(j *Job) {
 			defer wg.Done()
-			sem <- struct{}{}
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
 			defer func() { <-sem }()
-			fn := j.check.Fn
-			if fn == nil {
+			if j.check.Fn == nil {
 				return
 			}
 			t := time.Now()
-			fn(j)
+			j.problems = l.runJob(j)
 			j.duration = time.Since(t)
 		}(j)
 	}
 	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	for _, j := range jobs {
 		if stats != nil {
-			stats.Jobs = append(stats.Jobs, JobStat{j.check.ID, j.duration})
+			stats.Jobs = append(stats.Jobs, JobStat{j.check.ID, j.duration, j.skipped})
 		}
 		for _, p := range j.problems {
-			allowedChecks := FilterChecks(allChecks, p.Package.Config.Checks)
+			if p.Checker == InternalError {
+				// Internal errors cannot be ignored and may not
+				// belong to any package.
+				out = append(out, p)
+				continue
+			}
+			if _, ok := allowedChecks[p.Package]; !ok {
+				checkConfig(p.Package)
+			}
+			allowed := allowedChecks[p.Package]
 
-			if l.ignore(p) {
+			if sev, ok := severities[checkSeverities[p.Package][p.Check]]; ok {
+				p.Severity = sev
+			}
+			if ignored, d := l.ignore(p); ignored {
 				p.Severity = Ignored
+				p.Suppression = d
 			}
-			// TODO(dh): support globs in check white/blacklist
-			// OPT(dh): this approach doesn't actually disable checks,
-			// it just discards their results. For the moment, that's
-			// fine. None of our checks are super expensive. In the
-			// future, we may want to provide opt-in expensive
-			// analysis, which shouldn't run at all. It may be easiest
-			// to implement this in the individual checks.
-			if (l.ReturnIgnored || p.Severity != Ignored) && allowedChecks[p.Check] {
+			// Checks that look at the entire program, such as
+			// unused, may still report problems in packages they are
+			// disabled in.
+			if (l.ReturnIgnored || p.Severity != Ignored) && allowed[p.Check] {
 				out = append(out, p)
 			}
 		}
 	}
 
-	for _, ig := range l.automaticIgnores {
-		ig, ok := ig.(*LineIgnore)
-		if !ok {
+	out = append(out, configProblems(pkgs, configErrs, l.Checkers, allChecks)...)
+
+	for _, d := range l.directives {
+		if d.Expired(l.now) {
+			out = append(out, Problem{
+				Position: d.Position,
+				Text:     fmt.Sprintf("this linter directive expired on %s: %s", d.Until.Format("2006-01-02"), d.Reason),
+				Check:    "",
+				Checker:  "lint",
+				Package:  nil,
+			})
 			continue
 		}
-		if ig.matched {
+		if d.Matched {
 			continue
 		}
 
 		couldveMatched := false
 		for f, pkg := range prog.astFileMap {
-			if prog.Fset().Position(f.Pos()).Filename != ig.File {
+			if prog.Fset().Position(f.Pos()).Filename != d.Position.Filename {
 				continue
 			}
-			allowedChecks := FilterChecks(allChecks, pkg.Config.Checks)
-			for _, c := range ig.Checks {
-				if !allowedChecks[c] {
+			for _, c := range d.Checks {
+				if !allowedChecks[pkg][c] {
 					continue
 				}
 				couldveMatched = true
@@ -519,7 +1155,7 @@ func (l *Linter) Lint(initial []*packages.Package, stats *PerfStats) []Problem {
 			continue
 		}
 		p := Problem{
-			Position: prog.DisplayPosition(ig.pos),
+			Position: d.Position,
 			Text:     "this linter directive didn't match anything; should it be removed?",
 			Check:    "",
 			Checker:  "lint",
@@ -528,6 +1164,126 @@ func (l *Linter) Lint(initial []*packages.Package, stats *PerfStats) []Problem {
 		out = append(out, p)
 	}
 
+	if l.PrintStats && stats != nil {
+		stats.Print(os.Stderr)
+	}
+
+	return SortProblems(out), nil
+}
+
+// InternalError is the checker name of problems that report failures
+// of checks, such as panics and timeouts, rather than problems in the
+// checked code.
+const InternalError = "internal error"
+
+// runJob runs the check of j and returns the problems it found. A
+// check that panics while looking at several packages is rerun for
+// each package individually, to find the packages that trigger the
+// panic and to salvage the problems found in all others. Checks of
+// whole-program checkers aren't rerun, because their results are
+// wrong when they only see parts of the program; only the failure
+// is reported.
+func (l *Linter) runJob(j *Job) []Problem {
+	ps, ok := l.tryJob(j)
+	if ok || len(j.Program.InitialPackages) < 2 || j.wholeProgram {
+		return ps
+	}
+	var out []Problem
+	for _, pkg := range j.Program.InitialPackages {
+		pj := &Job{
+			Program: j.Program.restrict(map[*Pkg]bool{pkg: true}),
+			ctx:     j.ctx,
+			checker: j.checker,
+			check:   j.check,
+		}
+		ps, _ := l.tryJob(pj)
+		out = append(out, ps...)
+	}
+	return out
+}
+
+// tryJob runs the check of j, recovering from panics and enforcing
+// the job timeout. It reports whether the check ran to completion
+// without panicking; problems describing the failure are returned
+// otherwise.
+func (l *Linter) tryJob(j *Job) ([]Problem, bool) {
+	ctx := j.ctx
+	if l.JobTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.JobTimeout)
+		defer cancel()
+	}
+
+	// The check runs on a copy of the job, so that an abandoned
+	// check cannot race with us.
+	wj := &Job{
+		Program: j.Program,
+		ctx:     ctx,
+		checker: j.checker,
+		check:   j.check,
+	}
+	type outcome struct {
+		panicked bool
+		value    interface{}
+		stack    []byte
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- outcome{panicked: true, value: r, stack: debug.Stack()}
+			}
+		}()
+		wj.check.Fn(wj)
+		done <- outcome{}
+	}()
+
+	select {
+	case o := <-done:
+		if o.panicked {
+			return []Problem{j.internalError(fmt.Sprintf("panic: %v\n\n%s", o.value, o.stack))}, false
+		}
+		return wj.problems, true
+	case <-ctx.Done():
+		if j.ctx.Err() != nil {
+			// Linting as a whole was cancelled
+			return nil, true
+		}
+		// Checks don't have to respond to cancellation, in which case
+		// the goroutine keeps running until the check returns on its
+		// own.
+		return []Problem{j.internalError(fmt.Sprintf("timed out after %s", l.JobTimeout))}, true
+	}
+}
+
+// internalError returns a problem describing a failure of j's check.
+func (j *Job) internalError(msg string) Problem {
+	p := Problem{
+		Check:    j.check.ID,
+		Checker:  InternalError,
+		Severity: Error,
+	}
+	if pkgs := j.Program.InitialPackages; len(pkgs) == 1 {
+		p.Package = pkgs[0]
+		if len(pkgs[0].GoFiles) > 0 {
+			p.Position.Filename = pkgs[0].GoFiles[0]
+		}
+		p.Text = fmt.Sprintf("internal error in check %s while checking package %s: %s", j.check.ID, pkgs[0].PkgPath, msg)
+	} else {
+		p.Text = fmt.Sprintf("internal error in check %s: %s", j.check.ID, msg)
+	}
+	return p
+}
+
+// Context returns the context of the job. Long-running checks
+// should stop once it is done.
+func (j *Job) Context() context.Context {
+	return j.ctx
+}
+
+// SortProblems sorts problems by position and message and removes
+// duplicates.
+func SortProblems(out []Problem) []Problem {
 	sort.Slice(out, func(i int, j int) bool {
 		pi, pj := out[i].Position, out[j].Position
 
@@ -544,10 +1300,6 @@ func (l *Linter) Lint(initial []*packages.Package, stats *PerfStats) []Problem {
 		return out[i].Text < out[j].Text
 	})
 
-	if l.PrintStats && stats != nil {
-		stats.Print(os.Stderr)
-	}
-
 	if len(out) < 2 {
 		return out
 	}
@@ -618,12 +1370,23 @@ type Pkg struct {
 	SSA *ssa.Package
 	*packages.Package
 	Config config.Config
+	// GoVersion is the minor Go version the package targets.
+	GoVersion int
+
+	// generated and filterGenerated are derived from Config.
+	generated       *generatedConfig
+	filterGenerated map[string]bool
 }
 
 type Positioner interface {
 	Pos() token.Pos
 }
 
+type Ranger interface {
+	Pos() token.Pos
+	End() token.Pos
+}
+
 func (prog *Program) DisplayPosition(p token.Pos) token.Position {
 	// Only use the adjusted position if it points to another Go file.
 	// This means we'll point to the original file for cgo files, but
@@ -638,7 +1401,45 @@ func (prog *Program) DisplayPosition(p token.Pos) token.Position {
 	return pos
 }
 
-func (prog *Program) isGenerated(path string) bool {
+// restrict returns a view of prog whose initial packages, functions
+// and files are limited to those of pkgs. All other state is shared
+// with prog.
+func (prog *Program) restrict(pkgs map[*Pkg]bool) *Program {
+	view := &Program{
+		SSA:              prog.SSA,
+		AllPackages:      prog.AllPackages,
+		AllFunctions:     prog.AllFunctions,
+		GoVersion:        prog.GoVersion,
+		ImportObjectFact: prog.ImportObjectFact,
+		tokenFileMap:     prog.tokenFileMap,
+		astFileMap:       prog.astFileMap,
+		packagesMap:      prog.packagesMap,
+		genMu:            prog.genMu,
+		generatedMap:     prog.generatedMap,
+	}
+	ssapkgs := map[*ssa.Package]bool{}
+	for _, pkg := range prog.InitialPackages {
+		if pkgs[pkg] {
+			view.InitialPackages = append(view.InitialPackages, pkg)
+			ssapkgs[pkg.SSA] = true
+		}
+	}
+	for _, fn := range prog.InitialFunctions {
+		if ssapkgs[fn.Pkg] {
+			view.InitialFunctions = append(view.InitialFunctions, fn)
+		}
+	}
+	for _, f := range prog.Files {
+		if pkgs[prog.astFileMap[f]] {
+			view.Files = append(view.Files, f)
+		}
+	}
+	return view
+}
+
+// isGenerated reports whether the file at path, which belongs to pkg,
+// is generated.
+func (prog *Program) isGenerated(pkg *Pkg, path string) bool {
 	// This function isn't very efficient in terms of lock contention
 	// and lack of parallelism, but it really shouldn't matter.
 	// Projects consists of thousands of files, and have hundreds of
@@ -657,23 +1458,74 @@ func (prog *Program) isGenerated(path string) bool {
 		return b
 	}
 
+	var gc *generatedConfig
+	if pkg != nil {
+		gc = pkg.generated
+	}
+	if gc != nil && gc.matchPath(pkg.Types.Path(), path) {
+		prog.generatedMap[path] = true
+		return true
+	}
 	f, err := os.Open(path)
 	if err != nil {
 		return false
 	}
 	defer f.Close()
-	b := isGenerated(f)
+	var headers []*regexp.Regexp
+	if gc != nil {
+		headers = gc.headers
+	}
+	b := isGenerated(f, headers)
 	prog.generatedMap[path] = b
 	return b
 }
 
+// Edit returns an edit that replaces the source code spanned by node
+// with text.
+func (j *Job) Edit(node Ranger, text string) Edit {
+	fset := j.Program.SSA.Fset
+	return Edit{
+		Start:   fset.PositionFor(node.Pos(), false),
+		End:     fset.PositionFor(node.End(), false),
+		NewText: text,
+	}
+}
+
+// ErrorfWithFix is like Errorf, but attaches edits to the problem as a
+// suggested fix.
+func (j *Job) ErrorfWithFix(n Positioner, edits []Edit, format string, args ...interface{}) *Problem {
+	p := j.Errorf(n, format, args...)
+	if p != nil {
+		p.Edits = edits
+	}
+	return p
+}
+
+// Relate attaches a related position to p, which may be nil. It has
+// to be called before the job reports any further problems.
+func (j *Job) Relate(p *Problem, n Positioner, format string, args ...interface{}) {
+	if p == nil {
+		return
+	}
+	p.Related = append(p.Related, Related{
+		Position: j.Program.DisplayPosition(n.Pos()),
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
 func (j *Job) Errorf(n Positioner, format string, args ...interface{}) *Problem {
 	tf := j.Program.SSA.Fset.File(n.Pos())
 	f := j.Program.tokenFileMap[tf]
 	pkg := j.Program.astFileMap[f]
 
 	pos := j.Program.DisplayPosition(n.Pos())
-	if j.Program.isGenerated(pos.Filename) && j.check.FilterGenerated {
+	filter := j.check.FilterGenerated
+	if pkg != nil {
+		if v, ok := pkg.filterGenerated[j.check.ID]; ok {
+			filter = v
+		}
+	}
+	if filter && j.Program.isGenerated(pkg, pos.Filename) {
 		return nil
 	}
 	problem := Problem{
@@ -683,6 +1535,11 @@ func (j *Job) Errorf(n Positioner, format string, args ...interface{}) *Problem
 		Checker:  j.checker,
 		Package:  pkg,
 	}
+	if r, ok := n.(Ranger); ok && r.End().IsValid() {
+		if end := j.Program.DisplayPosition(r.End()); end.Filename == pos.Filename {
+			problem.End = end
+		}
+	}
 	j.problems = append(j.problems, problem)
 	return &j.problems[len(j.problems)-1]
 }
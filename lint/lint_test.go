@@ -1,8 +1,19 @@
 package lint_test
 
 import (
+	"context"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"golang.org/x/tools/go/packages"
+	"honnef.co/go/tools/config"
 	. "honnef.co/go/tools/lint"
 	"honnef.co/go/tools/lint/testutil"
 )
@@ -15,7 +26,7 @@ func (testChecker) Init(prog *Program) {}
 
 func (testChecker) Checks() []Check {
 	return []Check{
-		{ID: "TEST1000", FilterGenerated: false, Fn: testLint},
+		{ID: "TEST1000", FilterGenerated: false, Fn: testLint, Doc: &Documentation{Title: "Test check", Since: "Unreleased"}},
 	}
 }
 
@@ -32,3 +43,326 @@ func TestAll(t *testing.T) {
 	c := testChecker{}
 	testutil.TestAll(t, c, "")
 }
+
+type skipChecker struct {
+	mu   sync.Mutex
+	seen map[string][]string
+}
+
+func (*skipChecker) Name() string       { return "skip" }
+func (*skipChecker) Prefix() string     { return "TEST" }
+func (*skipChecker) Init(prog *Program) {}
+
+func (c *skipChecker) Checks() []Check {
+	return []Check{
+		{ID: "TEST1001", Fn: c.record("TEST1001")},
+		{ID: "TEST1002", Fn: c.record("TEST1002")},
+	}
+}
+
+func (c *skipChecker) record(id string) Func {
+	return func(j *Job) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for _, pkg := range j.Program.InitialPackages {
+			c.seen[id] = append(c.seen[id], pkg.Types.Path())
+		}
+	}
+}
+
+func TestDisabledChecks(t *testing.T) {
+	gopath, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := &packages.Config{
+		Mode: packages.LoadAllSyntax,
+		Env:  append(os.Environ(), "GOPATH="+gopath, "GO111MODULE=off"),
+	}
+	pkgs, err := packages.Load(conf, "Disabled/a", "Disabled/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &skipChecker{seen: map[string][]string{}}
+	l := &Linter{
+		Checkers: []Checker{c},
+		Config:   config.Config{Checks: []string{"inherit", "-TEST1002"}},
+	}
+	stats := &PerfStats{CheckerInits: map[string]time.Duration{}}
+	if _, err := l.Lint(context.Background(), pkgs, stats); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]string{"TEST1001": {"Disabled/a"}}
+	if !reflect.DeepEqual(c.seen, want) {
+		t.Errorf("checks saw packages %v, want %v", c.seen, want)
+	}
+	if want := []string{"TEST1002"}; !reflect.DeepEqual(stats.SkippedJobs, want) {
+		t.Errorf("got skipped jobs %v, want %v", stats.SkippedJobs, want)
+	}
+	if len(stats.Jobs) != 1 || stats.Jobs[0].SkippedPackages != 1 {
+		t.Errorf("got job stats %v, want TEST1001 skipping 1 package", stats.Jobs)
+	}
+}
+
+type failChecker struct{}
+
+func (failChecker) Name() string       { return "fail" }
+func (failChecker) Prefix() string     { return "TEST" }
+func (failChecker) Init(prog *Program) {}
+
+func (failChecker) Checks() []Check {
+	return []Check{
+		{ID: "TEST1003", Fn: panicky},
+		{ID: "TEST1004", Fn: func(j *Job) { <-j.Context().Done() }},
+	}
+}
+
+func panicky(j *Job) {
+	for _, pkg := range j.Program.InitialPackages {
+		if pkg.Types.Path() == "Disabled/b" {
+			panic("boom")
+		}
+	}
+	for _, pkg := range j.Program.InitialPackages {
+		j.Errorf(pkg.Syntax[0], "found a problem")
+	}
+}
+
+func TestFailingChecks(t *testing.T) {
+	gopath, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := &packages.Config{
+		Mode: packages.LoadAllSyntax,
+		Env:  append(os.Environ(), "GOPATH="+gopath, "GO111MODULE=off"),
+	}
+	pkgs, err := packages.Load(conf, "Disabled/a", "Disabled/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := &Linter{
+		Checkers:   []Checker{failChecker{}},
+		Config:     config.Config{Checks: []string{"all"}},
+		JobTimeout: 50 * time.Millisecond,
+	}
+	summarize := func(ps []Problem) []string {
+		var out []string
+		for _, p := range ps {
+			text := p.Text
+			if i := strings.IndexByte(text, '\n'); i != -1 {
+				// strip the stack trace
+				text = text[:i]
+			}
+			out = append(out, p.Checker+": "+text)
+		}
+		sort.Strings(out)
+		return out
+	}
+	ps, err := l.Lint(context.Background(), pkgs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := summarize(ps)
+	want := []string{
+		// Disabled/b's configuration is written for skipChecker.
+		`config: "TEST1001" doesn't match any check`,
+		"fail: found a problem",
+		"internal error: internal error in check TEST1003 while checking package Disabled/b: panic: boom",
+		"internal error: internal error in check TEST1004: timed out after 50ms",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got problems %q, want %q", got, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := l.Lint(ctx, pkgs, nil); err != context.Canceled {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+
+	// Whole-program checks aren't rerun per package, because their
+	// results would be wrong.
+	l.Checkers = []Checker{wholeFailChecker{}}
+	ps, err = l.Lint(context.Background(), pkgs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = summarize(ps)
+	want = []string{
+		`config: "TEST1001" doesn't match any check`,
+		"internal error: internal error in check TEST1003: panic: boom",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got problems %q, want %q", got, want)
+	}
+}
+
+type wholeFailChecker struct{ failChecker }
+
+func (wholeFailChecker) WholeProgram() bool { return true }
+
+func (wholeFailChecker) Checks() []Check {
+	return []Check{{ID: "TEST1003", Fn: panicky}}
+}
+
+type generatedChecker struct{}
+
+func (generatedChecker) Name() string       { return "generated" }
+func (generatedChecker) Prefix() string     { return "TEST" }
+func (generatedChecker) Init(prog *Program) {}
+
+func (generatedChecker) Checks() []Check {
+	return []Check{
+		{ID: "TEST1005", FilterGenerated: true, Fn: generatedLint("TEST1005")},
+		{ID: "TEST1006", FilterGenerated: false, Fn: generatedLint("TEST1006")},
+		{ID: "TEST1007", FilterGenerated: true, Fn: generatedLint("TEST1007")},
+	}
+}
+
+func generatedLint(id string) Func {
+	return func(j *Job) {
+		for _, fn := range j.Program.InitialFunctions {
+			if fn.Synthetic == "" {
+				// Problems with identical positions and texts are
+				// merged, so include the check's ID.
+				j.Errorf(fn, "problem found by %s", id)
+			}
+		}
+	}
+}
+
+func TestGeneratedFiles(t *testing.T) {
+	gopath, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := &packages.Config{
+		Mode: packages.LoadAllSyntax,
+		Env:  append(os.Environ(), "GOPATH="+gopath, "GO111MODULE=off"),
+	}
+	pkgs, err := packages.Load(conf, "Generated/pkg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := &Linter{
+		Checkers: []Checker{generatedChecker{}},
+		Config:   config.Config{Checks: []string{"all"}},
+	}
+	ps, err := l.Lint(context.Background(), pkgs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string][]string{}
+	for _, p := range ps {
+		got[p.Check] = append(got[p.Check], filepath.Base(p.Position.Filename))
+	}
+	for _, files := range got {
+		sort.Strings(files)
+	}
+	want := map[string][]string{
+		"TEST1005": {"normal.go"},
+		"TEST1006": {"normal.go"},
+		"TEST1007": {"header.go", "normal.go", "proto.pb.go", "standard.go"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got problems in %v, want %v", got, want)
+	}
+}
+
+func TestCheckSeverities(t *testing.T) {
+	allChecks := []string{"S1000", "SA1000", "SA5000", "SA5001", "SA9000"}
+	config := map[string]string{
+		"all":    "warning",
+		"SA*":    "info",
+		"SA5*":   "error",
+		"SA5001": "off",
+	}
+	want := map[string]string{
+		"S1000":  "warning",
+		"SA1000": "info",
+		"SA5000": "error",
+		"SA5001": "off",
+		"SA9000": "info",
+	}
+	if got := CheckSeverities(allChecks, config); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestApplyEdits(t *testing.T) {
+	src := []byte("_ = time.Now().Sub(t1)\n")
+	edit := func(start, end int, text string) Edit {
+		return Edit{
+			Start:   token.Position{Filename: "f.go", Offset: start},
+			End:     token.Position{Filename: "f.go", Offset: end},
+			NewText: text,
+		}
+	}
+
+	got, err := ApplyEdits(src, []Edit{edit(4, 22, "time.Since(t1)"), edit(0, 1, "x")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "x = time.Since(t1)\n"; string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// identical edits are only applied once
+	got, err = ApplyEdits(src, []Edit{edit(0, 1, "x"), edit(0, 1, "x")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "x = time.Now().Sub(t1)\n"; string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// even if they aren't adjacent in the input
+	got, err = ApplyEdits(src, []Edit{edit(0, 1, "x"), edit(4, 22, "time.Since(t1)"), edit(0, 1, "x")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "x = time.Since(t1)\n"; string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	for _, edits := range [][]Edit{
+		{edit(4, 10, "a"), edit(8, 12, "b")},
+		{edit(0, 1, "x"), edit(0, 1, "y"), edit(0, 1, "x")},
+		{edit(0, 0, "x"), edit(0, 0, "y")},
+	} {
+		if _, err := ApplyEdits(src, edits); err != ErrConflictingEdits {
+			t.Errorf("%v: got error %v, want %v", edits, err, ErrConflictingEdits)
+		}
+	}
+}
+
+func TestInitDocs(t *testing.T) {
+	docs := map[string]*Documentation{
+		"SA1000": {},
+		"SA9000": {},
+		"ST1000": {},
+		"ST1005": {},
+	}
+	InitDocs(docs, map[string]string{"SA": "Static analysis", "SA9": "Dubious code", "ST": "Style"})
+	tests := []struct {
+		id         string
+		category   string
+		nonDefault bool
+	}{
+		{"SA1000", "Static analysis", false},
+		{"SA9000", "Dubious code", false},
+		{"ST1000", "Style", true},
+		{"ST1005", "Style", false},
+	}
+	for _, tt := range tests {
+		doc := docs[tt.id]
+		if doc.Category != tt.category || doc.NonDefault != tt.nonDefault {
+			t.Errorf("%s: got category %q, non-default %t, want %q, %t", tt.id, doc.Category, doc.NonDefault, tt.category, tt.nonDefault)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+// Package lintanalysis exposes lint.Checkers as analyzers for the
+// golang.org/x/tools/go/analysis framework.
+//
+// Every check becomes its own analyzer, named after the check's ID,
+// so that drivers such as unitchecker, multichecker or go vet
+// -vettool can enable, run and report checks individually:
+//
+//	unitchecker.Main(lintanalysis.Analyzers(
+//		simple.NewChecker(),
+//		staticcheck.NewChecker(),
+//	)...)
+package lintanalysis // import "honnef.co/go/tools/lint/lintanalysis"
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+	"honnef.co/go/tools/config"
+	"honnef.co/go/tools/facts"
+	"honnef.co/go/tools/lint"
+	"honnef.co/go/tools/ssa"
+)
+
+// prepared is the result of the shared analyzer: a package in the
+// form the linter expects, its SSA form, and the facts about its
+// dependencies.
+type prepared struct {
+	pkg   *packages.Package
+	files map[string]*token.File
+	ssa   *ssa.Program
+	facts *facts.Store
+}
+
+// Analyzers returns one analyzer per check of the provided checkers.
+//
+// All returned analyzers depend on a shared analyzer that builds the
+// SSA form of each package and exchanges facts about dependencies,
+// such as deprecated objects and function purity, which would
+// otherwise require the dependencies' syntax. Each analyzer only
+// runs its own check.
+//
+// Checkers aren't safe for concurrent use, which is why checks run
+// one at a time. Analyzers should only be called once per process,
+// because a fact type may only be registered by a single analyzer.
+func Analyzers(cs ...lint.Checker) []*analysis.Analyzer {
+	r := &runner{linters: map[lint.Checker]*lint.Linter{}}
+	shared := &analysis.Analyzer{
+		Name:       "lint",
+		Doc:        "prepare packages for lint checks and record facts about them",
+		Run:        r.prepare,
+		ResultType: reflect.TypeOf((*prepared)(nil)),
+	}
+	for _, fact := range facts.Types() {
+		shared.FactTypes = append(shared.FactTypes, fact)
+	}
+
+	var out []*analysis.Analyzer
+	for _, c := range cs {
+		for _, check := range c.Checks() {
+			c, check := c, check
+			out = append(out, &analysis.Analyzer{
+				Name:     check.ID,
+				Doc:      doc(c, check),
+				Requires: []*analysis.Analyzer{shared},
+				Run: func(pass *analysis.Pass) (interface{}, error) {
+					return nil, r.run(pass, pass.ResultOf[shared].(*prepared), c, check)
+				},
+			})
+		}
+	}
+	return out
+}
+
+// doc returns the documentation of an analyzer, whose first line is
+// its summary.
+func doc(c lint.Checker, check lint.Check) string {
+	if check.Doc == nil {
+		return fmt.Sprintf("%s check %s", c.Name(), check.ID)
+	}
+	if check.Doc.Text == "" {
+		return check.Doc.Title
+	}
+	return check.Doc.Title + "\n\n" + strings.TrimSpace(check.Doc.Text)
+}
+
+type runner struct {
+	// mu serializes all work of the analyzers, including preparing
+	// packages, even when the driver analyzes packages in parallel.
+	// Checkers aren't safe for concurrent use, and checks of
+	// different checkers share SSA programs.
+	mu sync.Mutex
+	// linters keep the state of the checkers, which they initialize
+	// once per SSA program, between checks.
+	linters map[lint.Checker]*lint.Linter
+}
+
+// singleCheck is a checker that only has one of the checks of
+// another checker.
+type singleCheck struct {
+	lint.Checker
+	check lint.Check
+}
+
+func (c singleCheck) Checks() []lint.Check { return []lint.Check{c.check} }
+
+// run runs check on the prepared package and reports its problems.
+func (r *runner) run(pass *analysis.Pass, prep *prepared, c lint.Checker, check lint.Check) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, ok := r.linters[c]
+	if !ok {
+		l = &lint.Linter{}
+		r.linters[c] = l
+	}
+	l.Checkers = []lint.Checker{singleCheck{c, check}}
+	// Selecting the analyzer enables the check, even if it is
+	// disabled by default or by configuration files.
+	l.Config = config.Config{Checks: []string{check.ID}}
+	l.SSA = prep.ssa
+	l.ImportObjectFact = prep.facts.Import
+	ps, err := l.Lint(context.Background(), []*packages.Package{prep.pkg}, nil)
+	if err != nil {
+		return err
+	}
+
+	pos := func(p token.Position) token.Pos {
+		tf, ok := prep.files[p.Filename]
+		if !ok || p.Offset > tf.Size() {
+			return token.NoPos
+		}
+		return tf.Pos(p.Offset)
+	}
+	for _, p := range ps {
+		if p.Check != check.ID {
+			// problems with linter directives don't belong to any
+			// check
+			continue
+		}
+		d := analysis.Diagnostic{
+			Pos:      pos(p.Position),
+			Category: p.Check,
+			Message:  p.Text,
+		}
+		if len(p.Edits) > 0 {
+			fix := analysis.SuggestedFix{Message: p.Text}
+			for _, e := range p.Edits {
+				fix.TextEdits = append(fix.TextEdits, analysis.TextEdit{
+					Pos:     pos(e.Start),
+					End:     pos(e.End),
+					NewText: []byte(e.NewText),
+				})
+			}
+			d.SuggestedFixes = []analysis.SuggestedFix{fix}
+		}
+		pass.Report(d)
+	}
+	return nil
+}
+
+// prepare builds the SSA form of a package, exports the facts about
+// it and collects those about its dependencies.
+func (r *runner) prepare(pass *analysis.Pass) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pkg := &packages.Package{
+		ID:         pass.Pkg.Path(),
+		Name:       pass.Pkg.Name(),
+		PkgPath:    pass.Pkg.Path(),
+		Fset:       pass.Fset,
+		Syntax:     pass.Files,
+		Types:      pass.Pkg,
+		TypesInfo:  pass.TypesInfo,
+		TypesSizes: pass.TypesSizes,
+		Imports:    map[string]*packages.Package{},
+	}
+	files := map[string]*token.File{}
+	for _, f := range pass.Files {
+		tf := pass.Fset.File(f.Pos())
+		files[tf.Name()] = tf
+		pkg.GoFiles = append(pkg.GoFiles, tf.Name())
+	}
+	seen := map[*types.Package]*packages.Package{}
+	for _, imp := range pass.Pkg.Imports() {
+		pkg.Imports[imp.Path()] = dependency(imp, pass.Fset, seen)
+	}
+
+	fc := &factsChecker{pass: pass}
+	l := &lint.Linter{
+		Checkers: []lint.Checker{fc},
+		ImportObjectFact: func(obj types.Object, fact lint.Fact) bool {
+			return pass.ImportObjectFact(obj, fact)
+		},
+	}
+	if _, err := l.Lint(context.Background(), []*packages.Package{pkg}, nil); err != nil {
+		return nil, err
+	}
+
+	store := facts.NewStore()
+	for _, f := range pass.AllObjectFacts() {
+		store.Export(f.Object, f.Fact)
+	}
+	return &prepared{pkg: pkg, files: files, ssa: l.SSA, facts: store}, nil
+}
+
+// dependency returns a syntax-less package for pkg and its
+// dependencies. Information that would require syntax is provided by
+// facts instead.
+func dependency(pkg *types.Package, fset *token.FileSet, seen map[*types.Package]*packages.Package) *packages.Package {
+	if p, ok := seen[pkg]; ok {
+		return p
+	}
+	p := &packages.Package{
+		ID:      pkg.Path(),
+		Name:    pkg.Name(),
+		PkgPath: pkg.Path(),
+		Fset:    fset,
+		Types:   pkg,
+		Imports: map[string]*packages.Package{},
+	}
+	seen[pkg] = p
+	for _, imp := range pkg.Imports() {
+		p.Imports[imp.Path()] = dependency(imp, fset, seen)
+	}
+	return p
+}
+
+// factsChecker is a pseudo checker without any checks. It uses the
+// fully initialized program to export facts about the package being
+// analysed.
+type factsChecker struct {
+	pass *analysis.Pass
+}
+
+func (*factsChecker) Name() string         { return "facts" }
+func (*factsChecker) Prefix() string       { return "" }
+func (*factsChecker) Checks() []lint.Check { return nil }
+
+func (fc *factsChecker) Init(prog *lint.Program) {
+	for _, pkg := range prog.InitialPackages {
+		facts.Compute(prog, pkg, func(obj types.Object, fact lint.Fact) {
+			fc.pass.ExportObjectFact(obj, fact)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package lintanalysis_test
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/analysistest"
+	"honnef.co/go/tools/lint"
+	"honnef.co/go/tools/lint/lintanalysis"
+	"honnef.co/go/tools/simple"
+	"honnef.co/go/tools/staticcheck"
+	"honnef.co/go/tools/stylecheck"
+)
+
+// countingChecker counts how often each of its checks runs.
+type countingChecker struct {
+	runs map[string]int
+}
+
+func (*countingChecker) Name() string       { return "counting" }
+func (*countingChecker) Prefix() string     { return "TEST" }
+func (*countingChecker) Init(*lint.Program) {}
+func (c *countingChecker) Checks() []lint.Check {
+	check := func(id string) lint.Check {
+		return lint.Check{ID: id, Fn: func(*lint.Job) { c.runs[id]++ }}
+	}
+	return []lint.Check{check("TEST1000"), check("TEST1001")}
+}
+
+func find(analyzers []*analysis.Analyzer, name string) *analysis.Analyzer {
+	for _, a := range analyzers {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+func TestAnalyzers(t *testing.T) {
+	c := &countingChecker{runs: map[string]int{}}
+	analyzers := lintanalysis.Analyzers(simple.NewChecker(), staticcheck.NewChecker(), stylecheck.NewChecker(), c)
+
+	s1005 := find(analyzers, "S1005")
+	if s1005 == nil {
+		t.Fatal("no analyzer for S1005")
+	}
+	if !strings.HasPrefix(s1005.Doc, "Drop unnecessary use of the blank identifier\n\n") {
+		t.Errorf("S1005 has documentation %q, want its title and text", s1005.Doc)
+	}
+
+	// Selected analyzers only report their own problems; S1002 would
+	// flag the comparison with true. SA1019 relies on facts about
+	// the dependency b.
+	analysistest.Run(t, analysistest.TestData(), s1005, "simple")
+	analysistest.Run(t, analysistest.TestData(), find(analyzers, "SA1019"), "deprecated")
+	// Selecting a check that is disabled by default runs it.
+	analysistest.Run(t, analysistest.TestData(), find(analyzers, "ST1003"), "names")
+
+	// Selected analyzers only run their own check.
+	analysistest.Run(t, analysistest.TestData(), find(analyzers, "TEST1000"), "plain")
+	if c.runs["TEST1000"] == 0 || c.runs["TEST1001"] != 0 {
+		t.Errorf("got runs %v, want only TEST1000 to run", c.runs)
+	}
+}
@@ -0,0 +1,6 @@
+package b
+
+// Deprecated: Use New instead.
+func Old() {}
+
+func New() {}
@@ -0,0 +1,7 @@
+package deprecated
+
+import "b"
+
+func fn() {
+	b.Old() // want `b.Old is deprecated: Use New instead.`
+}
@@ -0,0 +1,3 @@
+package names
+
+func Do_something() {} // want `should not use underscores in Go names`
@@ -0,0 +1,8 @@
+package simple
+
+func fn(s []int, ok bool) {
+	for _ = range s { // want `should omit values from range`
+	}
+	if ok == true {
+	}
+}
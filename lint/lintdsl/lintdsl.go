@@ -228,7 +228,12 @@ func DereferenceR(T types.Type) types.Type {
 	return T
 }
 
-func IsGoVersion(j *lint.Job, minor int) bool {
+// IsGoVersion reports whether the package containing node targets Go
+// 1.minor or later.
+func IsGoVersion(j *lint.Job, node lint.Positioner, minor int) bool {
+	if pkg := j.NodePackage(node); pkg != nil {
+		return pkg.GoVersion >= minor
+	}
 	return j.Program.GoVersion >= minor
 }
 
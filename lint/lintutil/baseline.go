@@ -0,0 +1,278 @@
+package lintutil
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+	"honnef.co/go/tools/lint"
+)
+
+// A Baseline is a set of known problems that should not be reported.
+// It allows adopting new checks in existing code bases without first
+// having to fix all existing problems.
+//
+// Problems are identified by their check, file, enclosing function
+// and message, as well as a hash of the source line they were found
+// on. Line numbers aren't part of the key, so that unrelated changes
+// to a file don't invalidate its entries.
+type Baseline struct {
+	path    string
+	entries []baselineEntry
+}
+
+// A baselineEntry is stored as a single line of JSON. File is
+// relative to the directory of the baseline file and uses forward
+// slashes.
+type baselineEntry struct {
+	Check   string `json:"check"`
+	File    string `json:"file"`
+	Func    string `json:"func,omitempty"`
+	Message string `json:"message"`
+	Anchor  string `json:"anchor"`
+	// Line is the line the problem was found on when the baseline
+	// was written. It is informative only.
+	Line int `json:"line"`
+
+	// pos is the position of the entry in the baseline file.
+	pos int
+}
+
+func (e baselineEntry) key() baselineEntry {
+	return baselineEntry{Check: e.Check, File: e.File, Func: e.Func, Message: e.Message, Anchor: e.Anchor}
+}
+
+// LoadBaseline reads the baseline file at path.
+func LoadBaseline(path string) (*Baseline, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	b := &Baseline{path: path}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var e baselineEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: malformed baseline entry: %s", path, line, err)
+		}
+		e.pos = line
+		b.entries = append(b.entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// baselined reports whether p can be part of a baseline. Ignored
+// problems, problems that don't belong to any check, compilation
+// errors, internal errors and problems that aren't in any file
+// can't.
+func baselined(p lint.Problem) bool {
+	return p.Severity != lint.Ignored &&
+		p.Check != "" &&
+		p.Checker != "compiler" &&
+		p.Checker != lint.InternalError &&
+		p.Position.Filename != ""
+}
+
+// WriteBaseline writes a baseline containing ps to path, leaving out
+// problems that can't be part of a baseline, such as internal errors.
+func WriteBaseline(path string, ps []lint.Problem) (int, error) {
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return 0, err
+	}
+	ac := newAnchorer()
+	var entries []baselineEntry
+	for _, p := range ps {
+		if !baselined(p) {
+			continue
+		}
+		e, err := ac.entry(dir, p)
+		if err != nil {
+			return 0, err
+		}
+		entries = append(entries, e)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		ei, ej := entries[i], entries[j]
+		if ei.File != ej.File {
+			return ei.File < ej.File
+		}
+		if ei.Line != ej.Line {
+			return ei.Line < ej.Line
+		}
+		return ei.Check < ej.Check
+	})
+
+	var buf bytes.Buffer
+	for _, e := range entries {
+		b, err := json.Marshal(e)
+		if err != nil {
+			return 0, err
+		}
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+	return len(entries), ioutil.WriteFile(path, buf.Bytes(), 0666)
+}
+
+// filter marks the problems in ps that are part of the baseline as
+// ignored, dropping them unless returnIgnored is set. Baseline
+// entries that don't match any problem are reported, as long as they
+// belong to one of the linted packages.
+func (b *Baseline) filter(ps []lint.Problem, pkgs []*packages.Package, returnIgnored bool) ([]lint.Problem, error) {
+	dir, err := filepath.Abs(filepath.Dir(b.path))
+	if err != nil {
+		return nil, err
+	}
+	remaining := map[baselineEntry][]baselineEntry{}
+	for _, e := range b.entries {
+		remaining[e.key()] = append(remaining[e.key()], e)
+	}
+
+	ac := newAnchorer()
+	out := ps[:0]
+	for _, p := range ps {
+		if baselined(p) {
+			e, err := ac.entry(dir, p)
+			if err != nil {
+				return nil, err
+			}
+			if es := remaining[e.key()]; len(es) > 0 {
+				remaining[e.key()] = es[1:]
+				p.Severity = lint.Ignored
+			}
+		}
+		if returnIgnored || p.Severity != lint.Ignored {
+			out = append(out, p)
+		}
+	}
+
+	files := map[string]bool{}
+	dirs := map[string]bool{}
+	for _, pkg := range pkgs {
+		for _, f := range pkg.GoFiles {
+			files[f] = true
+			dirs[filepath.Dir(f)] = true
+		}
+	}
+	var stale []baselineEntry
+	for _, es := range remaining {
+		for _, e := range es {
+			file := filepath.Join(dir, filepath.FromSlash(e.File))
+			if files[file] {
+				stale = append(stale, e)
+			} else if _, err := os.Stat(file); os.IsNotExist(err) && dirs[filepath.Dir(file)] {
+				// the file has been deleted
+				stale = append(stale, e)
+			}
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool { return stale[i].pos < stale[j].pos })
+	for _, e := range stale {
+		out = append(out, lint.Problem{
+			Position: token.Position{Filename: b.path, Line: e.pos, Column: 1},
+			Text:     "this baseline entry didn't match anything; should it be removed?",
+			Checker:  "lint",
+		})
+	}
+	return out, nil
+}
+
+// anchorer computes baseline entries for problems, caching the
+// contents of files.
+type anchorer struct {
+	fset  *token.FileSet
+	files map[string]*anchorFile
+}
+
+type anchorFile struct {
+	lines [][]byte
+	ast   *ast.File
+}
+
+func newAnchorer() *anchorer {
+	return &anchorer{fset: token.NewFileSet(), files: map[string]*anchorFile{}}
+}
+
+func (ac *anchorer) file(name string) *anchorFile {
+	if f, ok := ac.files[name]; ok {
+		return f
+	}
+	f := &anchorFile{}
+	ac.files[name] = f
+	src, err := ioutil.ReadFile(name)
+	if err != nil {
+		return f
+	}
+	f.lines = bytes.Split(src, []byte("\n"))
+	// A file that fails to parse still gets partial syntax, which is
+	// good enough for finding functions.
+	f.ast, _ = parser.ParseFile(ac.fset, name, src, 0)
+	return f
+}
+
+func (ac *anchorer) entry(dir string, p lint.Problem) (baselineEntry, error) {
+	name := p.Position.Filename
+	rel, err := filepath.Rel(dir, name)
+	if err != nil {
+		return baselineEntry{}, err
+	}
+	f := ac.file(name)
+	var line []byte
+	if p.Position.Line > 0 && p.Position.Line <= len(f.lines) {
+		line = f.lines[p.Position.Line-1]
+	}
+	// Anchor on the line's content, ignoring changes in whitespace.
+	sum := sha256.Sum256([]byte(strings.Join(strings.Fields(string(line)), " ")))
+	return baselineEntry{
+		Check:   p.Check,
+		File:    filepath.ToSlash(rel),
+		Func:    ac.enclosingFunc(f, p.Position.Line),
+		Message: p.Text,
+		Anchor:  hex.EncodeToString(sum[:8]),
+		Line:    p.Position.Line,
+	}, nil
+}
+
+// enclosingFunc returns the name of the function declaration that
+// contains line, in the form F or (*T).M.
+func (ac *anchorer) enclosingFunc(f *anchorFile, line int) string {
+	if f.ast == nil {
+		return ""
+	}
+	for _, decl := range f.ast.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		if ac.fset.Position(fn.Pos()).Line > line || ac.fset.Position(fn.End()).Line < line {
+			continue
+		}
+		if fn.Recv == nil || len(fn.Recv.List) == 0 {
+			return fn.Name.Name
+		}
+		return fmt.Sprintf("(%s).%s", types.ExprString(fn.Recv.List[0].Type), fn.Name.Name)
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+package lintutil
+
+import (
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"honnef.co/go/tools/lint"
+)
+
+func TestBaseline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "baseline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "a.go")
+	src := "package a\n\nfunc F() {\n\tx := 1\n\t_ = x\n}\n"
+	if err := ioutil.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	problem := func(line int, check, text string) lint.Problem {
+		return lint.Problem{
+			Position: token.Position{Filename: file, Line: line, Column: 2},
+			Text:     text,
+			Check:    check,
+			Checker:  "test",
+		}
+	}
+	nofile := lint.Problem{Text: "known", Check: "TEST1000", Checker: "test"}
+	internal := func(line int) lint.Problem {
+		p := problem(line, "TEST1000", "known")
+		p.Checker = lint.InternalError
+		return p
+	}
+
+	path := filepath.Join(dir, "baseline.json")
+	n, err := WriteBaseline(path, []lint.Problem{problem(4, "TEST1000", "known"), nofile, internal(4)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("wrote %d entries, want 1", n)
+	}
+
+	// Moving the line doesn't affect the entry, but changing it
+	// does.
+	src = "package a\n\n// F does things.\n\nfunc F() {\n\tx := 1\n\t_ = x\n\ty := 2\n}\n"
+	if err := ioutil.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	b, err := LoadBaseline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps := []lint.Problem{
+		problem(6, "TEST1000", "known"),
+		problem(8, "TEST1000", "known"),
+		problem(6, "TEST1001", "known"),
+		nofile,
+		internal(6),
+	}
+	out, err := b.filter(ps, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []lint.Severity{lint.Ignored, lint.Error, lint.Error, lint.Error, lint.Error}
+	if len(out) != len(want) {
+		t.Fatalf("got %d problems, want %d", len(out), len(want))
+	}
+	for i, p := range out {
+		if p.Severity != want[i] {
+			t.Errorf("%s at line %d: got severity %d, want %d", p.Check, p.Position.Line, p.Severity, want[i])
+		}
+	}
+}
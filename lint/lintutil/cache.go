@@ -0,0 +1,427 @@
+package lintutil
+
+import (
+	"context"
+	"encoding/json"
+	"go/build"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/tools/go/packages"
+	"honnef.co/go/tools/config"
+	"honnef.co/go/tools/internal/cache"
+	"honnef.co/go/tools/lint"
+	"honnef.co/go/tools/version"
+)
+
+// cacheVersion has to be incremented whenever the format of cache
+// entries or the computation of keys changes.
+const cacheVersion = 8
+
+// cacheEntry is what we store per package: the problems found in the
+// package and its linter directives.
+type cacheEntry struct {
+	Problems   []cachedProblem
+	Directives []lint.Directive
+}
+
+// expired reports whether one of the entry's directives expired
+// since the entry was stored, which changes the problems we have to
+// report.
+func (e *cacheEntry) expired(now time.Time) bool {
+	for _, d := range e.Directives {
+		if !d.Expired(now) {
+			continue
+		}
+		reported := false
+		for _, p := range e.Problems {
+			if p.Check == "" && p.Position == d.Position {
+				reported = true
+				break
+			}
+		}
+		if !reported {
+			return true
+		}
+	}
+	return false
+}
+
+type cachedProblem struct {
+	Position token.Position
+	End      token.Position
+	Text     string
+	Check    string
+	Checker  string
+	Severity lint.Severity
+	Edits    []lint.Edit
+	Related  []lint.Related
+	// Suppression is the directive that suppressed the problem.
+	Suppression *lint.Directive
+}
+
+// keyer computes cache keys for packages. A package's key covers
+// everything that may affect the problems we report for it: the
+// contents of the package and of all of its dependencies, the
+// package's configuration, the set of checkers and their version,
+// and the options we were invoked with.
+type keyer struct {
+	// version covers the versions of the checkers and of Go, which
+	// affect all entries, including facts.
+	version cache.Key
+	base    cache.Key
+	goroot  string
+	content map[*packages.Package]cache.Key
+}
+
+var (
+	executableHashOnce sync.Once
+	executableHash     cache.Key
+)
+
+// checkerVersion returns a string identifying the version of the
+// checkers. Development builds don't have a meaningful version, so we
+// hash the running executable instead.
+func checkerVersion() string {
+	if version.Version != "devel" {
+		return version.Version
+	}
+	executableHashOnce.Do(func() {
+		h := cache.NewHash()
+		exe, err := os.Executable()
+		if err == nil {
+			var f *os.File
+			f, err = os.Open(exe)
+			if err == nil {
+				_, err = io.Copy(h, f)
+				f.Close()
+			}
+		}
+		if err != nil {
+			// We can't identify the binary; make sure that we don't
+			// reuse any entries.
+			h.Printf("%p", &err)
+		}
+		executableHash = h.Sum()
+	})
+	return "devel " + executableHash.String()
+}
+
+func newKeyer(cs []lint.Checker, opt *Options) *keyer {
+	h := cache.NewHash()
+	h.Printf("cache version %d\n", cacheVersion)
+	h.Printf("checker version %s\n", checkerVersion())
+	h.Printf("go %s %s/%s cgo=%t\n", runtime.Version(), build.Default.GOOS, build.Default.GOARCH, build.Default.CgoEnabled)
+	version := h.Sum()
+
+	h = cache.NewHash()
+	h.Printf("version %s\n", version)
+	h.Printf("target go 1.%d\n", opt.GoVersion)
+	h.Printf("export data %t\n", opt.ExportData)
+	h.Printf("tags %q\n", opt.Tags)
+	h.Printf("platform %s/%s\n", opt.GOOS, opt.GOARCH)
+	h.Printf("tests %t\n", opt.LintTests)
+	h.Printf("ignores %q\n", opt.Ignores)
+	h.Printf("return ignored %t\n", opt.ReturnIgnored)
+	for _, c := range cs {
+		h.Printf("checker %s\n", c.Name())
+		if ck, ok := c.(lint.CacheKeyer); ok {
+			h.Printf("checker key %q\n", ck.CacheKey())
+		}
+		for _, check := range c.Checks() {
+			h.Printf("check %s %t\n", check.ID, check.FilterGenerated)
+		}
+	}
+	return &keyer{
+		version: version,
+		base:    h.Sum(),
+		goroot:  filepath.Join(build.Default.GOROOT, "src") + string(filepath.Separator),
+		content: map[*packages.Package]cache.Key{},
+	}
+}
+
+// contentKey hashes the files of pkg and, recursively, the keys of its
+// dependencies.
+func (k *keyer) contentKey(pkg *packages.Package) (cache.Key, error) {
+	if key, ok := k.content[pkg]; ok {
+		return key, nil
+	}
+	h := cache.NewHash()
+	h.Printf("package %s\n", pkg.ID)
+	files := append(append([]string(nil), pkg.GoFiles...), pkg.OtherFiles...)
+	sort.Strings(files)
+	for _, file := range files {
+		if strings.HasPrefix(file, k.goroot) {
+			// The standard library is covered by the Go version.
+			h.Printf("file %s\n", file)
+			continue
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return cache.Key{}, err
+		}
+		fh := cache.NewHash()
+		_, err = io.Copy(fh, f)
+		f.Close()
+		if err != nil {
+			return cache.Key{}, err
+		}
+		h.Printf("file %s %s\n", file, fh.Sum())
+	}
+
+	paths := make([]string, 0, len(pkg.Imports))
+	for path := range pkg.Imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		key, err := k.contentKey(pkg.Imports[path])
+		if err != nil {
+			return cache.Key{}, err
+		}
+		h.Printf("import %s %s\n", path, key)
+	}
+
+	key := h.Sum()
+	k.content[pkg] = key
+	return key, nil
+}
+
+// key computes the key of an initial package that is configured by
+// cfg. cfgErr is the error loading the configuration, if any.
+func (k *keyer) key(pkg *packages.Package, cfg config.Config, cfgErr error) (cache.Key, error) {
+	content, err := k.contentKey(pkg)
+	if err != nil {
+		return cache.Key{}, err
+	}
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return cache.Key{}, err
+	}
+	h := cache.NewHash()
+	h.Printf("base %s\ncontent %s\nconfig %s\n", k.base, content, b)
+	if cfgErr != nil {
+		h.Printf("config error %s\n", cfgErr)
+	}
+	if len(pkg.GoFiles) > 0 {
+		v, ok := config.ModuleGoVersion(filepath.Dir(pkg.GoFiles[0]))
+		h.Printf("module go %d %t\n", v, ok)
+	}
+	return h.Sum(), nil
+}
+
+// factsKey computes the key of the facts about a dependency. Unlike
+// problems, facts don't depend on configuration or options.
+func (k *keyer) factsKey(pkg *packages.Package) (cache.Key, error) {
+	content, err := k.contentKey(pkg)
+	if err != nil {
+		return cache.Key{}, err
+	}
+	h := cache.NewHash()
+	h.Printf("facts\nversion %s\ncontent %s\n", k.version, content)
+	return h.Sum(), nil
+}
+
+// packageConfig returns the configuration of pkg, computed the same
+// way the linter computes it. Errors are reported by the linter, but
+// they are part of the package's key.
+func packageConfig(pkg *packages.Package, cfg config.Config) (config.Config, error) {
+	if len(pkg.GoFiles) == 0 {
+		return config.Config{}, nil
+	}
+	pcfg, err := config.LoadPackage(filepath.Dir(pkg.GoFiles[0]), pkg.PkgPath)
+	return pcfg.Merge(cfg), err
+}
+
+// isTestMain reports whether pkg is a synthesized test main package.
+// These consist entirely of generated code and cannot be loaded
+// individually, so we don't lint them when using the cache.
+func isTestMain(pkg *packages.Package) bool {
+	return pkg.Name == "main" && strings.HasSuffix(pkg.ID, ".test")
+}
+
+// lintCached lints packages, reusing the problems of packages whose
+// cache keys haven't changed. Only packages that miss the cache are
+// loaded from source.
+//
+// The problems of whole-program checkers depend on all packages that
+// are linted together, not just on a package and its dependencies,
+// so they are never cached.
+func lintCached(ctx context.Context, c *cache.Cache, cs []lint.Checker, paths []string, opt *Options, ignores []lint.Ignore, stats *lint.PerfStats) (*result, error) {
+	for _, ch := range cs {
+		if lint.IsWholeProgram(ch) {
+			return lintUncached(ctx, cs, paths, opt, ignores, stats)
+		}
+	}
+	conf := &packages.Config{
+		Context: ctx,
+		Mode:    packages.LoadImports,
+		Tests:   opt.LintTests,
+		Env:     buildEnv(opt),
+		BuildFlags: []string{
+			"-tags=" + strings.Join(opt.Tags, " "),
+		},
+	}
+	pkgs, err := packages.Load(conf, paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	k := newKeyer(cs, opt)
+	now := time.Now().UTC()
+	var (
+		problems   []lint.Problem
+		directives []lint.Directive
+	)
+	keys := map[string]cache.Key{}
+	missed := map[string]bool{}
+	var dirs []string
+	seenDirs := map[string]bool{}
+	for _, pkg := range pkgs {
+		if isTestMain(pkg) {
+			continue
+		}
+		if len(pkg.GoFiles) == 0 {
+			// Let the full load report whatever is wrong with the
+			// package.
+			return lintUncached(ctx, cs, paths, opt, ignores, stats)
+		}
+		cfg, cfgErr := packageConfig(pkg, opt.Config)
+		key, err := k.key(pkg, cfg, cfgErr)
+		if err == nil && len(pkg.Errors) == 0 {
+			var e cacheEntry
+			if c.Get(key, &e) && !e.expired(now) {
+				lpkg := &lint.Pkg{Package: pkg, Config: cfg}
+				for _, cp := range e.Problems {
+					problems = append(problems, lint.Problem{
+						Position:    cp.Position,
+						End:         cp.End,
+						Text:        cp.Text,
+						Check:       cp.Check,
+						Checker:     cp.Checker,
+						Package:     lpkg,
+						Severity:    cp.Severity,
+						Edits:       cp.Edits,
+						Related:     cp.Related,
+						Suppression: cp.Suppression,
+					})
+				}
+				directives = append(directives, e.Directives...)
+				continue
+			}
+			keys[pkg.ID] = key
+		}
+		missed[pkg.ID] = true
+		dir := filepath.Dir(pkg.GoFiles[0])
+		if !seenDirs[dir] {
+			seenDirs[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	if len(dirs) == 0 {
+		return &result{problems: lint.SortProblems(problems), packages: pkgs, directives: directives}, nil
+	}
+
+	conf.Mode = loadMode(opt)
+	all, err := packages.Load(conf, dirs...)
+	if err != nil {
+		return nil, err
+	}
+	var workingPkgs []*packages.Package
+	for _, pkg := range all {
+		if !missed[pkg.ID] {
+			continue
+		}
+		if pkg.IllTyped {
+			problems = append(problems, compileErrors(pkg)...)
+		} else {
+			workingPkgs = append(workingPkgs, pkg)
+		}
+	}
+	if len(workingPkgs) == 0 {
+		return &result{problems: lint.SortProblems(problems), packages: pkgs, directives: directives}, nil
+	}
+
+	l := newLinter(cs, opt, ignores)
+	if opt.ExportData {
+		store, err := dependencyFacts(ctx, c, k, conf, workingPkgs)
+		if err != nil {
+			return nil, err
+		}
+		l.ImportObjectFact = store.Import
+	}
+	ps, err := l.Lint(ctx, workingPkgs, stats)
+	if err != nil {
+		return nil, err
+	}
+	ds := l.Directives()
+
+	// Attribute problems to the packages they were found in. Problems
+	// without a package, such as those about linter directives, as
+	// well as directives themselves are attributed to the package
+	// containing their file.
+	fileOwner := map[string]*packages.Package{}
+	for _, pkg := range workingPkgs {
+		for _, f := range pkg.CompiledGoFiles {
+			if _, ok := fileOwner[f]; !ok {
+				fileOwner[f] = pkg
+			}
+		}
+	}
+	entries := map[*packages.Package]*cacheEntry{}
+	for _, pkg := range workingPkgs {
+		entries[pkg] = &cacheEntry{}
+	}
+	failed := map[*packages.Package]bool{}
+	for _, p := range ps {
+		var owner *packages.Package
+		if p.Package != nil {
+			owner = p.Package.Package
+		} else {
+			owner = fileOwner[p.Position.Filename]
+		}
+		if p.Checker == lint.InternalError {
+			// Don't cache the results of checks that failed. Failures
+			// that aren't specific to a package, such as timeouts,
+			// affect the results of all packages.
+			failed[owner] = true
+		}
+		if e, ok := entries[owner]; ok {
+			e.Problems = append(e.Problems, cachedProblem{
+				Position:    p.Position,
+				End:         p.End,
+				Text:        p.Text,
+				Check:       p.Check,
+				Checker:     p.Checker,
+				Severity:    p.Severity,
+				Edits:       p.Edits,
+				Related:     p.Related,
+				Suppression: p.Suppression,
+			})
+		}
+	}
+	for _, d := range ds {
+		if e, ok := entries[fileOwner[d.Position.Filename]]; ok {
+			e.Directives = append(e.Directives, d)
+		}
+	}
+	for pkg, e := range entries {
+		key, ok := keys[pkg.ID]
+		if !ok || failed[pkg] || failed[nil] {
+			continue
+		}
+		// Failing to write to the cache only costs us performance.
+		_ = c.Put(key, e)
+	}
+
+	problems = append(problems, ps...)
+	directives = append(directives, ds...)
+	return &result{problems: lint.SortProblems(problems), packages: pkgs, directives: directives}, nil
+}
@@ -0,0 +1,138 @@
+package lintutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+	"honnef.co/go/tools/config"
+	"honnef.co/go/tools/internal/cache"
+	"honnef.co/go/tools/lint"
+	"honnef.co/go/tools/unused"
+)
+
+type keyChecker struct {
+	key string
+}
+
+func (keyChecker) Name() string         { return "key" }
+func (keyChecker) Prefix() string       { return "TEST" }
+func (keyChecker) Init(*lint.Program)   {}
+func (keyChecker) Checks() []lint.Check { return []lint.Check{{ID: "TEST1000"}} }
+func (c keyChecker) CacheKey() string   { return c.key }
+
+func TestCacheKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	dep := &packages.Package{ID: "dep", GoFiles: []string{write("dep.go", "package dep\n")}}
+	pkg := &packages.Package{
+		ID:      "pkg",
+		GoFiles: []string{write("pkg.go", "package pkg\n")},
+		Imports: map[string]*packages.Package{"dep": dep},
+	}
+	cfg := config.Config{Checks: []string{"all"}}
+	opt := &Options{}
+
+	// Keyers memoize content keys, so every key uses a new one, like
+	// every run does.
+	key := func(cs []lint.Checker, cfg config.Config) cache.Key {
+		k, err := newKeyer(cs, opt).key(pkg, cfg, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+	cs := []lint.Checker{keyChecker{"a"}}
+	base := key(cs, cfg)
+	if key(cs, cfg) != base {
+		t.Fatal("keys of unchanged packages differ")
+	}
+
+	seen := map[cache.Key]string{base: "base"}
+	check := func(name string, k cache.Key) {
+		if prev, ok := seen[k]; ok {
+			t.Errorf("%s has the same key as %s", name, prev)
+		}
+		seen[k] = name
+	}
+	check("checker option", key([]lint.Checker{keyChecker{"b"}}, cfg))
+	check("config change", key(cs, config.Config{Checks: []string{"all", "-TEST1000"}}))
+	write("pkg.go", "package pkg\n\nvar X int\n")
+	check("content change", key(cs, cfg))
+	write("dep.go", "package dep\n\nvar Y int\n")
+	check("dependency change", key(cs, cfg))
+}
+
+func TestCacheWholeProgram(t *testing.T) {
+	dir, err := ioutil.TempDir("", "whole")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, data string) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.mod", "module example.com\n")
+	write("a/a.go", "package a\n\nfunc Foo() {}\n\nfunc Baz() {}\n")
+	writeB := func(body string) {
+		write("b/b.go", "package main\n\nimport \"example.com/a\"\n\nfunc main() {\n\ta.Baz()\n"+body+"}\n")
+	}
+	writeB("\ta.Foo()\n")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	c, err := cache.Open(filepath.Join(dir, "cache"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opt := &Options{Cache: c, Config: config.Config{Checks: []string{"all"}}}
+	unusedFoo := func() bool {
+		uc := unused.NewChecker(unused.CheckAll)
+		uc.WholeProgram = true
+		ps, err := Lint([]lint.Checker{unused.NewLintChecker(uc)}, []string{"./..."}, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range ps {
+			if p.Check == "U1000" && filepath.Base(p.Position.Filename) == "a.go" {
+				return true
+			}
+		}
+		return false
+	}
+
+	if unusedFoo() {
+		t.Fatal("Foo is reported as unused while b uses it")
+	}
+	// Only b changes, but a's problems depend on it.
+	writeB("")
+	if !unusedFoo() {
+		t.Error("Foo isn't reported as unused after b stopped using it")
+	}
+}
@@ -0,0 +1,218 @@
+package lintutil
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"honnef.co/go/tools/lint"
+)
+
+// changes records which lines of which files have been added or
+// modified. Files are identified by their absolute paths.
+type changes struct {
+	lines map[string]map[int]bool
+	// files are files that have been added in their entirety, such
+	// as untracked files.
+	files map[string]bool
+}
+
+func newChanges() *changes {
+	return &changes{lines: map[string]map[int]bool{}, files: map[string]bool{}}
+}
+
+func (c *changes) changed(file string, line int) bool {
+	return c.files[file] || c.lines[file][line]
+}
+
+// filter returns the problems in ps that are on changed lines. A
+// problem is also kept if one of its related positions is on a
+// changed line, or if one of the edits of its suggested fix touches
+// a changed line. Problems that aren't in any file, such as failures
+// to load packages, and failed checks are always kept.
+func (c *changes) filter(ps []lint.Problem) []lint.Problem {
+	var out []lint.Problem
+	for _, p := range ps {
+		if c.relevant(p) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func (c *changes) relevant(p lint.Problem) bool {
+	if p.Position.Filename == "" || p.Checker == lint.InternalError {
+		return true
+	}
+	if c.changed(p.Position.Filename, p.Position.Line) {
+		return true
+	}
+	for _, r := range p.Related {
+		if c.changed(r.Position.Filename, r.Position.Line) {
+			return true
+		}
+	}
+	for _, e := range p.Edits {
+		for line := e.Start.Line; line <= e.End.Line; line++ {
+			if c.changed(e.Start.Filename, line) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseDiff parses a unified diff and records the lines it adds.
+// File names are resolved relative to dir. The "a/" and "b/" prefixes
+// used by git are stripped, and names quoted by git are unquoted.
+func parseDiff(r io.Reader, dir string) (*changes, error) {
+	c := newChanges()
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1024*1024)
+	var (
+		file string
+		line int
+		// remaining number of lines in the old and new versions of
+		// the hunk
+		oldRemaining, remaining int
+	)
+	for n := 1; sc.Scan(); n++ {
+		l := sc.Text()
+		switch {
+		case oldRemaining > 0 || remaining > 0:
+			switch {
+			case strings.HasPrefix(l, "+"):
+				if file != "" {
+					c.lines[file][line] = true
+				}
+				line++
+				remaining--
+			case strings.HasPrefix(l, "-"):
+				oldRemaining--
+			case strings.HasPrefix(l, `\`):
+				// "\ No newline at end of file"
+			default:
+				// context line
+				line++
+				oldRemaining--
+				remaining--
+			}
+		case strings.HasPrefix(l, "+++ "):
+			name := strings.TrimPrefix(l, "+++ ")
+			if i := strings.IndexByte(name, '\t'); i != -1 {
+				name = name[:i]
+			}
+			if strings.HasPrefix(name, `"`) {
+				// git quotes names that contain unusual characters,
+				// escaping them like C does, which Go's syntax
+				// for strings is a superset of.
+				unquoted, err := strconv.Unquote(name)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: malformed file name %s", n, name)
+				}
+				name = unquoted
+			}
+			if name == "/dev/null" {
+				// the file has been deleted
+				file = ""
+				continue
+			}
+			name = strings.TrimPrefix(name, "b/")
+			file = filepath.Join(dir, filepath.FromSlash(name))
+			if c.lines[file] == nil {
+				c.lines[file] = map[int]bool{}
+			}
+		case strings.HasPrefix(l, "@@ "):
+			// @@ -l,s +l,s @@ optional section heading
+			fields := strings.Fields(l)
+			if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+				return nil, fmt.Errorf("line %d: malformed hunk header %q", n, l)
+			}
+			_, oldCount, err1 := parseRange(fields[1][1:])
+			start, count, err2 := parseRange(fields[2][1:])
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("line %d: malformed hunk header %q", n, l)
+			}
+			line, oldRemaining, remaining = start, oldCount, count
+		}
+	}
+	return c, sc.Err()
+}
+
+// parseRange parses the range "l,s" of a hunk header. The size
+// defaults to 1.
+func parseRange(r string) (start, size int, err error) {
+	size = 1
+	if i := strings.IndexByte(r, ','); i != -1 {
+		size, err = strconv.Atoi(r[i+1:])
+		if err != nil {
+			return 0, 0, err
+		}
+		r = r[:i]
+	}
+	start, err = strconv.Atoi(r)
+	return start, size, err
+}
+
+// gitChanges returns the changes in the working tree of the git
+// repository containing the current directory, relative to rev.
+// Untracked files count as changed in their entirety.
+func gitChanges(rev string) (*changes, error) {
+	git := func(args ...string) ([]byte, error) {
+		var stderr bytes.Buffer
+		cmd := exec.Command("git", args...)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("git %s: %s: %s", args[0], err, bytes.TrimSpace(stderr.Bytes()))
+		}
+		return out, nil
+	}
+
+	top, err := git("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	root := string(bytes.TrimSpace(top))
+	// Explicit prefixes override diff.noprefix and
+	// diff.mnemonicPrefix in the user's configuration.
+	d, err := git("diff", "--no-color", "--no-ext-diff", "--no-renames", "--src-prefix=a/", "--dst-prefix=b/", "-U0", rev, "--")
+	if err != nil {
+		return nil, err
+	}
+	c, err := parseDiff(bytes.NewReader(d), root)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := git("ls-files", "--others", "--exclude-standard", "--full-name", "-z", root)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range bytes.Split(untracked, []byte{0}) {
+		if len(name) > 0 {
+			c.files[filepath.Join(root, filepath.FromSlash(string(name)))] = true
+		}
+	}
+	return c, nil
+}
+
+// diffFileChanges returns the changes made by the unified diff in
+// file. File names in the diff are relative to the current directory.
+func diffFileChanges(file string) (*changes, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	return parseDiff(f, dir)
+}
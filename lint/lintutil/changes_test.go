@@ -0,0 +1,178 @@
+package lintutil
+
+import (
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"honnef.co/go/tools/lint"
+)
+
+func TestParseDiff(t *testing.T) {
+	dir := filepath.FromSlash("/repo")
+	path := func(name string) string { return filepath.Join(dir, name) }
+	tests := []struct {
+		name  string
+		diff  string
+		lines map[string][]int
+	}{
+		{
+			name: "modification",
+			diff: `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -3 +3,2 @@ func f() {
+-	x := 1
++	x := 2
++	y := 3
+`,
+			lines: map[string][]int{"a.go": {3, 4}},
+		},
+		{
+			name: "context lines",
+			diff: `--- a/a.go
++++ b/a.go
+@@ -1,3 +1,3 @@
+ package a
+-var x = 1
++var x = 2
+ var y = 1
+`,
+			lines: map[string][]int{"a.go": {2}},
+		},
+		{
+			name: "pure deletion",
+			diff: `--- a/a.go
++++ b/a.go
+@@ -3,2 +2,0 @@
+-	x := 1
+-	y := 2
+@@ -10 +9 @@
+-	z := 3
++	z := 4
+`,
+			lines: map[string][]int{"a.go": {9}},
+		},
+		{
+			name: "no newline at end of file",
+			diff: `--- a/a.go
++++ b/a.go
+@@ -5 +5,2 @@
+-}
+\ No newline at end of file
++}
++var x = 1
+\ No newline at end of file
+`,
+			lines: map[string][]int{"a.go": {5, 6}},
+		},
+		{
+			name: "deleted file",
+			diff: `--- a/a.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package a
+-var x = 1
+--- a/b.go
++++ b/b.go
+@@ -1,0 +2 @@
++var y = 1
+`,
+			lines: map[string][]int{"b.go": {2}},
+		},
+		{
+			name: "file headers inside hunks",
+			diff: `--- a/a.go
++++ b/a.go
+@@ -2,2 +2,2 @@
+---- x
++++++ y
+ var z
+`,
+			lines: map[string][]int{"a.go": {2}},
+		},
+		{
+			name: "quoted name",
+			diff: `--- "a/caf\303\251.go"
++++ "b/caf\303\251.go"
+@@ -1 +1 @@
+-package a
++package b
+`,
+			lines: map[string][]int{"café.go": {1}},
+		},
+		{
+			name:  "timestamp",
+			diff:  "--- a.go\t2019-01-01 00:00:00\n+++ a.go\t2019-01-02 00:00:00\n@@ -1 +1 @@\n-x\n+y\n",
+			lines: map[string][]int{"a.go": {1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := parseDiff(strings.NewReader(tt.diff), dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string][]int{}
+			for file, lines := range c.lines {
+				for line := 1; line < 100; line++ {
+					if lines[line] {
+						got[file] = append(got[file], line)
+					}
+				}
+			}
+			want := map[string][]int{}
+			for name, lines := range tt.lines {
+				want[path(name)] = lines
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got changed lines %v, want %v", got, want)
+			}
+		})
+	}
+
+	for _, diff := range []string{
+		"+++ b/a.go\n@@ -1 @@\n",
+		"+++ b/a.go\n@@ -1 +x,2 @@\n",
+		"+++ \"b/a.go\n",
+	} {
+		if _, err := parseDiff(strings.NewReader(diff), dir); err == nil {
+			t.Errorf("parsing %q succeeded, want an error", diff)
+		}
+	}
+}
+
+func TestChangesRelevant(t *testing.T) {
+	c := newChanges()
+	c.lines["a.go"] = map[int]bool{5: true}
+	c.files["new.go"] = true
+	pos := func(file string, line int) token.Position {
+		return token.Position{Filename: file, Line: line, Column: 1}
+	}
+	tests := []struct {
+		name string
+		p    lint.Problem
+		want bool
+	}{
+		{"changed line", lint.Problem{Position: pos("a.go", 5)}, true},
+		{"unchanged line", lint.Problem{Position: pos("a.go", 4)}, false},
+		{"new file", lint.Problem{Position: pos("new.go", 100)}, true},
+		{"related position", lint.Problem{
+			Position: pos("b.go", 1),
+			Related:  []lint.Related{{Position: pos("a.go", 5)}},
+		}, true},
+		{"edit", lint.Problem{
+			Position: pos("a.go", 1),
+			Edits:    []lint.Edit{{Start: pos("a.go", 3), End: pos("a.go", 6)}},
+		}, true},
+		{"no file", lint.Problem{Text: "couldn't load package"}, true},
+		{"internal error", lint.Problem{Position: pos("a.go", 4), Checker: lint.InternalError}, true},
+	}
+	for _, tt := range tests {
+		if got := c.relevant(tt.p); got != tt.want {
+			t.Errorf("%s: got %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
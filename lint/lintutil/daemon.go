@@ -0,0 +1,409 @@
+package lintutil
+
+import (
+	"context"
+	"fmt"
+	"go/build"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/tools/go/packages"
+	"honnef.co/go/tools/config"
+	"honnef.co/go/tools/lint"
+	"honnef.co/go/tools/lint/lintutil/format"
+	"honnef.co/go/tools/ssa"
+)
+
+// maxPrograms is the number of loaded programs the daemon keeps in
+// memory.
+const maxPrograms = 8
+
+// DefaultSocket returns the default path of the daemon's socket.
+func DefaultSocket() string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("staticcheck-%d.sock", os.Getuid()))
+}
+
+// LintArgs is a request to the daemon to lint packages.
+type LintArgs struct {
+	// Dir is the directory that Paths are relative to.
+	Dir       string   `json:"dir"`
+	Paths     []string `json:"paths"`
+	Tags      []string `json:"tags"`
+	LintTests bool     `json:"tests"`
+	GoVersion int      `json:"go"`
+	// Config is the configuration that the configuration files of
+	// the packages are applied to.
+	Config        config.Config `json:"config"`
+	Ignores       string        `json:"ignores"`
+	ReturnIgnored bool          `json:"return_ignored"`
+	// Baseline is the path of a baseline file, relative to Dir.
+	Baseline   string        `json:"baseline"`
+	JobTimeout time.Duration `json:"job_timeout"`
+	// Timeout, if positive, limits the time the daemon spends on the
+	// request.
+	Timeout time.Duration `json:"timeout"`
+	// Cache allows the daemon to reuse packages it loaded for
+	// earlier requests.
+	Cache bool `json:"cache"`
+}
+
+// LintReply is the daemon's answer to a LintArgs.
+type LintReply struct {
+	Problems []format.JSONProblem `json:"problems"`
+	// Directives are the linter directives in the linted packages.
+	Directives []format.JSONDirective `json:"directives"`
+	// Packages are the packages that were linted.
+	Packages []RemotePackage `json:"packages"`
+}
+
+// A RemotePackage describes a linted package.
+type RemotePackage struct {
+	ID              string   `json:"id"`
+	Name            string   `json:"name"`
+	PkgPath         string   `json:"path"`
+	GoFiles         []string `json:"go_files"`
+	CompiledGoFiles []string `json:"compiled_go_files"`
+}
+
+// A Daemon answers lint requests, keeping loaded programs in memory
+// between requests.
+//
+// Clients connect to the daemon's Unix socket and speak JSON-RPC 1.0,
+// as implemented by net/rpc/jsonrpc. The only method is "Linter.Lint",
+// whose single parameter is a LintArgs object and whose result is a
+// LintReply object. Problems are encoded like the objects printed by
+// the JSON formatter, with the optional fields of format.JSONProblem
+// filled in. Durations are in nanoseconds. A request is cancelled
+// when its client disconnects.
+type Daemon struct {
+	cs []lint.Checker
+
+	// mu serializes requests; checkers aren't safe for concurrent
+	// use.
+	mu       sync.Mutex
+	programs map[programKey]*program
+	// initialized is the program that the checkers were last
+	// initialized for.
+	initialized *ssa.Program
+}
+
+type programKey struct {
+	dir   string
+	tags  string
+	tests bool
+	paths string
+}
+
+// A program is a set of loaded packages, along with their SSA form.
+type program struct {
+	pkgs []*packages.Package
+	ssa  *ssa.Program
+	// stamps are the modification times of all files and
+	// directories the packages were loaded from.
+	stamps   map[string]time.Time
+	lastUsed time.Time
+}
+
+// current reports whether none of the program's files have changed
+// since it was loaded.
+func (prog *program) current() bool {
+	for path, mtime := range prog.stamps {
+		fi, err := os.Stat(path)
+		if err != nil || !fi.ModTime().Equal(mtime) {
+			return false
+		}
+	}
+	return true
+}
+
+func NewDaemon(cs []lint.Checker) *Daemon {
+	return &Daemon{cs: cs, programs: map[programKey]*program{}}
+}
+
+// Serve accepts connections on l until ctx is cancelled. Requests
+// are cancelled when their client disconnects.
+func (d *Daemon) Serve(ctx context.Context, l net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		connCtx, cancel := context.WithCancel(ctx)
+		srv := rpc.NewServer()
+		if err := srv.RegisterName("Linter", &session{d: d, ctx: connCtx}); err != nil {
+			cancel()
+			return err
+		}
+		go srv.ServeCodec(&cancelCodec{jsonrpc.NewServerCodec(conn), cancel})
+	}
+}
+
+// A session serves the requests of a single connection.
+type session struct {
+	d   *Daemon
+	ctx context.Context
+}
+
+// Lint lints the requested packages.
+func (s *session) Lint(args *LintArgs, reply *LintReply) error {
+	ctx := s.ctx
+	if args.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, args.Timeout)
+		defer cancel()
+	}
+	return s.d.lint(ctx, args, reply)
+}
+
+// cancelCodec cancels the requests of a connection once no more
+// requests can be read from it, which is when the client
+// disconnected. The server reads the next request while it is still
+// answering the previous one.
+type cancelCodec struct {
+	rpc.ServerCodec
+	cancel context.CancelFunc
+}
+
+func (c *cancelCodec) ReadRequestHeader(r *rpc.Request) error {
+	err := c.ServerCodec.ReadRequestHeader(r)
+	if err != nil {
+		c.cancel()
+	}
+	return err
+}
+
+func (d *Daemon) lint(ctx context.Context, args *LintArgs, reply *LintReply) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	opt := &Options{
+		Tags:          args.Tags,
+		LintTests:     args.LintTests,
+		Ignores:       args.Ignores,
+		GoVersion:     args.GoVersion,
+		ReturnIgnored: args.ReturnIgnored,
+		JobTimeout:    args.JobTimeout,
+		Config:        args.Config,
+	}
+	ignores, err := parseIgnore(opt.Ignores)
+	if err != nil {
+		return err
+	}
+	paths := args.Paths
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	prog, err := d.program(ctx, args.Dir, paths, opt, args.Cache)
+	if err != nil {
+		return err
+	}
+	var problems []lint.Problem
+	var working []*packages.Package
+	for _, pkg := range prog.pkgs {
+		if pkg.IllTyped {
+			problems = append(problems, compileErrors(pkg)...)
+		} else {
+			working = append(working, pkg)
+		}
+	}
+	if len(working) > 0 {
+		l := newLinter(d.cs, opt, ignores)
+		l.SSA = prog.ssa
+		l.Initialized = d.initialized
+		ps, err := l.Lint(ctx, working, nil)
+		prog.ssa, d.initialized = l.SSA, l.Initialized
+		if err != nil {
+			return err
+		}
+		problems = append(problems, ps...)
+		for _, d := range l.Directives() {
+			reply.Directives = append(reply.Directives, format.NewJSONDirective(d))
+		}
+	}
+	if args.Baseline != "" {
+		path := args.Baseline
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(args.Dir, path)
+		}
+		b, err := LoadBaseline(path)
+		if err != nil {
+			return err
+		}
+		problems, err = b.filter(problems, prog.pkgs, opt.ReturnIgnored)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, p := range lint.SortProblems(problems) {
+		reply.Problems = append(reply.Problems, format.NewJSONProblem(p))
+	}
+	for _, pkg := range prog.pkgs {
+		reply.Packages = append(reply.Packages, RemotePackage{
+			ID:              pkg.ID,
+			Name:            pkg.Name,
+			PkgPath:         pkg.PkgPath,
+			GoFiles:         pkg.GoFiles,
+			CompiledGoFiles: pkg.CompiledGoFiles,
+		})
+	}
+	return nil
+}
+
+// program returns the loaded program for paths, reusing an earlier
+// one if reuse is set and its files haven't changed.
+func (d *Daemon) program(ctx context.Context, dir string, paths []string, opt *Options, reuse bool) (*program, error) {
+	key := programKey{
+		dir:   dir,
+		tags:  strings.Join(opt.Tags, " "),
+		tests: opt.LintTests,
+		paths: strings.Join(paths, "\x00"),
+	}
+	if prog, ok := d.programs[key]; ok {
+		if reuse && prog.current() {
+			prog.lastUsed = time.Now()
+			return prog, nil
+		}
+		delete(d.programs, key)
+	}
+
+	conf := &packages.Config{
+		Context: ctx,
+		Mode:    packages.LoadAllSyntax,
+		Tests:   opt.LintTests,
+		Dir:     dir,
+		BuildFlags: []string{
+			"-tags=" + key.tags,
+		},
+	}
+	pkgs, err := packages.Load(conf, paths...)
+	if err != nil {
+		return nil, err
+	}
+	prog := &program{pkgs: pkgs, stamps: map[string]time.Time{}, lastUsed: time.Now()}
+	stamp := func(path string) {
+		if fi, err := os.Stat(path); err == nil {
+			prog.stamps[path] = fi.ModTime()
+		}
+	}
+	goroot := filepath.Clean(build.Default.GOROOT) + string(filepath.Separator)
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, files := range [][]string{pkg.GoFiles, pkg.OtherFiles} {
+			for _, f := range files {
+				if strings.HasPrefix(f, goroot) {
+					// The standard library only changes with the
+					// Go version.
+					continue
+				}
+				stamp(f)
+				// Catch files being added to the package.
+				stamp(filepath.Dir(f))
+			}
+		}
+	})
+	for p := dir; ; p = filepath.Dir(p) {
+		if _, err := os.Stat(filepath.Join(p, "go.mod")); err == nil {
+			stamp(filepath.Join(p, "go.mod"))
+			stamp(filepath.Join(p, "go.sum"))
+			break
+		}
+		if filepath.Dir(p) == p {
+			break
+		}
+	}
+
+	if len(d.programs) >= maxPrograms {
+		var oldest programKey
+		var t time.Time
+		for k, p := range d.programs {
+			if t.IsZero() || p.lastUsed.Before(t) {
+				oldest, t = k, p.lastUsed
+			}
+		}
+		delete(d.programs, oldest)
+	}
+	d.programs[key] = prog
+	return prog, nil
+}
+
+// listen listens on the Unix socket at path, replacing stale sockets
+// of daemons that are no longer running.
+func listen(path string) (net.Listener, error) {
+	l, err := net.Listen("unix", path)
+	if err == nil {
+		return l, nil
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("a daemon is already listening on %s", path)
+	}
+	if err := os.Remove(path); err != nil {
+		return nil, err
+	}
+	return net.Listen("unix", path)
+}
+
+// lintRemote asks the daemon listening on socket to lint packages.
+// If ctx is cancelled, the connection is closed, which cancels the
+// request.
+func lintRemote(ctx context.Context, socket string, args *LintArgs) (*result, error) {
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't connect to daemon: %s", err)
+	}
+	client := jsonrpc.NewClient(conn)
+	defer client.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		args.Timeout = time.Until(deadline)
+	}
+	var reply LintReply
+	select {
+	case call := <-client.Go("Linter.Lint", args, &reply, nil).Done:
+		if call.Error != nil {
+			return nil, call.Error
+		}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	res := &result{}
+	for _, jd := range reply.Directives {
+		res.directives = append(res.directives, jd.Directive())
+	}
+	pkgs := map[string]*lint.Pkg{}
+	for _, rp := range reply.Packages {
+		pkg := &packages.Package{
+			ID:              rp.ID,
+			Name:            rp.Name,
+			PkgPath:         rp.PkgPath,
+			GoFiles:         rp.GoFiles,
+			CompiledGoFiles: rp.CompiledGoFiles,
+		}
+		res.packages = append(res.packages, pkg)
+		pkgs[pkg.ID] = &lint.Pkg{Package: pkg}
+	}
+	for _, jp := range reply.Problems {
+		p := jp.Problem()
+		p.Package = pkgs[jp.Package]
+		res.problems = append(res.problems, p)
+	}
+	return res, nil
+}
@@ -0,0 +1,112 @@
+package lintutil
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"honnef.co/go/tools/config"
+	"honnef.co/go/tools/lint"
+)
+
+func TestDaemon(t *testing.T) {
+	dir, err := ioutil.TempDir("", "daemon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, data string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.mod", "module example.com/p\n")
+	write("p.go", `package p
+
+var bad int
+
+//lint:ignore TEST1000 it's fine
+var _ = bad
+`)
+
+	socket := filepath.Join(dir, "daemon.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := &nameChecker{}
+	done := make(chan error, 1)
+	go func() { done <- NewDaemon([]lint.Checker{c}).Serve(ctx, l) }()
+
+	args := &LintArgs{Dir: dir, Config: config.Config{Checks: []string{"all"}}, ReturnIgnored: true, Cache: true}
+	for i := 0; i < 2; i++ {
+		res, err := lintRemote(context.Background(), socket, args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.problems) != 2 {
+			t.Fatalf("got %d problems, want 2", len(res.problems))
+		}
+
+		// Everything that -fix, -diff and -list-ignores need makes
+		// it through the connection.
+		p := res.problems[0]
+		if p.Check != "TEST1000" || p.Severity == lint.Ignored {
+			t.Errorf("got %v, want an unignored TEST1000 problem", p)
+		}
+		if len(p.Edits) != 1 || p.Edits[0].NewText != "good" || p.Edits[0].Start.Offset != 15 {
+			t.Errorf("got edits %+v, want the rename at offset 15", p.Edits)
+		}
+		if len(p.Related) != 1 || p.Related[0].Message != "in this package" {
+			t.Errorf("got related positions %+v", p.Related)
+		}
+		if p.End.Column != 8 {
+			t.Errorf("got end %v, want column 8", p.End)
+		}
+		if p.Package == nil || p.Package.PkgPath != "example.com/p" {
+			t.Errorf("got package %v, want example.com/p", p.Package)
+		}
+		p = res.problems[1]
+		if p.Severity != lint.Ignored || p.Suppression == nil || p.Suppression.Reason != "it's fine" {
+			t.Errorf("got %v, suppressed by %v, want a problem suppressed by the directive", p, p.Suppression)
+		}
+		if len(res.directives) != 1 || !res.directives[0].Matched {
+			t.Errorf("got directives %+v, want one matched directive", res.directives)
+		}
+		if len(res.packages) != 1 || res.packages[0].PkgPath != "example.com/p" {
+			t.Errorf("got packages %v, want example.com/p", res.packages)
+		}
+	}
+	// The second request reused the program, and its checkers
+	// didn't have to be initialized again.
+	if c.inits != 1 {
+		t.Errorf("checker was initialized %d times, want 1", c.inits)
+	}
+
+	// Without the cache, the program is loaded again.
+	uncached := *args
+	uncached.Cache = false
+	if _, err := lintRemote(context.Background(), socket, &uncached); err != nil {
+		t.Fatal(err)
+	}
+	if c.inits != 2 {
+		t.Errorf("checker was initialized %d times, want 2", c.inits)
+	}
+
+	// Cancelling a request abandons it.
+	cctx, ccancel := context.WithCancel(context.Background())
+	ccancel()
+	if _, err := lintRemote(cctx, socket, args); err != context.Canceled {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
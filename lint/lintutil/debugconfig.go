@@ -0,0 +1,316 @@
+package lintutil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/tools/go/packages"
+	"honnef.co/go/tools/config"
+)
+
+// commandLine is the origin of configuration set by flags.
+const commandLine = "command line"
+
+// A configField is a field of a configuration, along with the
+// origins of its values.
+type configField struct {
+	name string
+	// Lists have values and origins, maps have keys, values and
+	// origins, and scalars have a single value and origin.
+	isMap   bool
+	keys    []string
+	values  []interface{}
+	origins []string
+}
+
+func configFields(cfg config.Config, o config.Origin) []configField {
+	list := func(name string, values, origins []string) configField {
+		f := configField{name: name, origins: origins}
+		for _, v := range values {
+			f.values = append(f.values, v)
+		}
+		return f
+	}
+	var fields []configField
+	fields = append(fields,
+		list("checks", cfg.Checks, o.Checks),
+		list("initialisms", cfg.Initialisms, o.Initialisms),
+		list("dot_import_whitelist", cfg.DotImportWhitelist, o.DotImportWhitelist),
+		list("http_status_code_whitelist", cfg.HTTPStatusCodeWhitelist, o.HTTPStatusCodeWhitelist),
+		list("generated_files", cfg.GeneratedFiles, o.GeneratedFiles),
+		list("generated_headers", cfg.GeneratedHeaders, o.GeneratedHeaders),
+	)
+	if cfg.GoVersion != "" {
+		fields = append(fields, configField{name: "go", values: []interface{}{cfg.GoVersion}, origins: []string{o.GoVersion}})
+	}
+
+	sev := configField{name: "severity", isMap: true}
+	for k := range cfg.Severity {
+		sev.keys = append(sev.keys, k)
+	}
+	sort.Strings(sev.keys)
+	for _, k := range sev.keys {
+		sev.values = append(sev.values, cfg.Severity[k])
+		sev.origins = append(sev.origins, o.Severity[k])
+	}
+	fg := configField{name: "filter_generated", isMap: true}
+	for k := range cfg.FilterGenerated {
+		fg.keys = append(fg.keys, k)
+	}
+	sort.Strings(fg.keys)
+	for _, k := range fg.keys {
+		fg.values = append(fg.values, cfg.FilterGenerated[k])
+		fg.origins = append(fg.origins, o.FilterGenerated[k])
+	}
+	fields = append(fields, sev, fg)
+
+	names := make([]string, 0, len(cfg.CheckerConfig))
+	for name := range cfg.CheckerConfig {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f := configField{name: "checker." + name, isMap: true}
+		for k := range cfg.CheckerConfig[name] {
+			f.keys = append(f.keys, k)
+		}
+		sort.Strings(f.keys)
+		for _, k := range f.keys {
+			f.values = append(f.values, cfg.CheckerConfig[name][k])
+			f.origins = append(f.origins, o.CheckerConfig[name][k])
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// A packageConfigTrace is the effective configuration of a package.
+type packageConfigTrace struct {
+	pkgpath string
+	dir     string
+	cfg     config.Config
+	origin  config.Origin
+}
+
+// traceConfigs returns the effective configuration of each package
+// matched by paths, including the configuration in opt.
+func traceConfigs(ctx context.Context, paths []string, opt *Options) ([]packageConfigTrace, []config.Problem, error) {
+	conf := &packages.Config{
+		Context: ctx,
+		Mode:    packages.LoadFiles,
+		Tests:   opt.LintTests,
+		Env:     buildEnv(opt),
+		BuildFlags: []string{
+			"-tags=" + strings.Join(opt.Tags, " "),
+		},
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	pkgs, err := packages.Load(conf, paths...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var out []packageConfigTrace
+	var problems []config.Problem
+	seen := map[string]bool{}
+	for _, pkg := range pkgs {
+		if len(pkg.GoFiles) == 0 || isTestMain(pkg) {
+			continue
+		}
+		pkgpath := strings.TrimSuffix(pkg.PkgPath, "_test")
+		if seen[pkgpath] {
+			continue
+		}
+		seen[pkgpath] = true
+
+		dir := filepath.Dir(pkg.GoFiles[0])
+		cfg, o, err := config.Trace(dir, pkg.PkgPath)
+		if err != nil {
+			cerr, ok := err.(*config.Error)
+			if !ok {
+				return nil, nil, err
+			}
+			problems = append(problems, cerr.Problems...)
+		}
+		cfg, o = cfg.MergeTraced(o, opt.Config, commandLine)
+		cfg, o = cfg.Normalize(o)
+		out = append(out, packageConfigTrace{pkgpath, dir, cfg, o})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].pkgpath < out[j].pkgpath
+	})
+	return out, problems, nil
+}
+
+// printConfigs prints the effective configuration of packages,
+// annotating each value with its origin. The format is one of "json",
+// "toml" and "text". In TOML, packages are an array of tables and
+// origins are comments.
+func printConfigs(w io.Writer, traces []packageConfigTrace, format string) error {
+	switch format {
+	case "json":
+		return printConfigsJSON(w, traces)
+	case "toml":
+		return printConfigsTOML(w, traces)
+	default:
+		return printConfigsText(w, traces)
+	}
+}
+
+func printConfigsText(w io.Writer, traces []packageConfigTrace) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for i, t := range traces {
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+		fmt.Fprintf(tw, "%s (%s)\n", t.pkgpath, t.dir)
+		for _, f := range configFields(t.cfg, t.origin) {
+			if len(f.values) == 0 {
+				continue
+			}
+			if f.name == "go" {
+				fmt.Fprintf(tw, "\tgo\t%v\t%s\n", f.values[0], f.origins[0])
+				continue
+			}
+			fmt.Fprintf(tw, "\t%s\n", f.name)
+			for j, v := range f.values {
+				if f.isMap {
+					fmt.Fprintf(tw, "\t\t%s = %v\t%s\n", f.keys[j], v, f.origins[j])
+				} else {
+					fmt.Fprintf(tw, "\t\t%v\t%s\n", v, f.origins[j])
+				}
+			}
+		}
+	}
+	return tw.Flush()
+}
+
+func printConfigsJSON(w io.Writer, traces []packageConfigTrace) error {
+	type value struct {
+		Value  interface{} `json:"value"`
+		Origin string      `json:"origin"`
+	}
+	enc := json.NewEncoder(w)
+	for _, t := range traces {
+		fields := map[string]interface{}{}
+		for _, f := range configFields(t.cfg, t.origin) {
+			switch {
+			case f.isMap:
+				m := map[string]value{}
+				for i, k := range f.keys {
+					m[k] = value{f.values[i], f.origins[i]}
+				}
+				fields[f.name] = m
+			case f.name == "go":
+				fields[f.name] = value{f.values[0], f.origins[0]}
+			default:
+				l := make([]value, len(f.values))
+				for i, v := range f.values {
+					l[i] = value{v, f.origins[i]}
+				}
+				fields[f.name] = l
+			}
+		}
+		jt := struct {
+			Package string                 `json:"package"`
+			Dir     string                 `json:"dir"`
+			Config  map[string]interface{} `json:"config"`
+		}{t.pkgpath, t.dir, fields}
+		if err := enc.Encode(jt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printConfigsTOML(w io.Writer, traces []packageConfigTrace) error {
+	key := func(k string) string {
+		if strings.IndexFunc(k, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-')
+		}) != -1 {
+			return strconv.Quote(k)
+		}
+		return k
+	}
+	var value func(v interface{}) string
+	value = func(v interface{}) string {
+		switch v := v.(type) {
+		case string:
+			return strconv.Quote(v)
+		case time.Time:
+			return v.Format(time.RFC3339Nano)
+		case []interface{}:
+			parts := make([]string, len(v))
+			for i, el := range v {
+				parts[i] = value(el)
+			}
+			return "[" + strings.Join(parts, ", ") + "]"
+		case []map[string]interface{}:
+			parts := make([]string, len(v))
+			for i, el := range v {
+				parts[i] = value(el)
+			}
+			return "[" + strings.Join(parts, ", ") + "]"
+		case map[string]interface{}:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			parts := make([]string, len(keys))
+			for i, k := range keys {
+				parts[i] = key(k) + " = " + value(v[k])
+			}
+			return "{" + strings.Join(parts, ", ") + "}"
+		default:
+			return fmt.Sprint(v)
+		}
+	}
+	for i, t := range traces {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "[[package]]\npath = %s\ndir = %s\n", value(t.pkgpath), value(t.dir))
+		var tables []configField
+		for _, f := range configFields(t.cfg, t.origin) {
+			switch {
+			case f.isMap:
+				// Subtables have to follow all keys of the package's
+				// table.
+				tables = append(tables, f)
+			case f.name == "go":
+				fmt.Fprintf(w, "go = %s # %s\n", value(f.values[0]), f.origins[0])
+			default:
+				fmt.Fprintf(w, "%s = [\n", f.name)
+				for j, v := range f.values {
+					fmt.Fprintf(w, "\t%s, # %s\n", value(v), f.origins[j])
+				}
+				fmt.Fprintln(w, "]")
+			}
+		}
+		for _, f := range tables {
+			if len(f.keys) == 0 {
+				continue
+			}
+			name := f.name
+			if strings.HasPrefix(name, "checker.") {
+				name = "checker." + key(strings.TrimPrefix(name, "checker."))
+			}
+			fmt.Fprintf(w, "[package.%s]\n", name)
+			for j, k := range f.keys {
+				fmt.Fprintf(w, "%s = %s # %s\n", key(k), value(f.values[j]), f.origins[j])
+			}
+		}
+	}
+	return nil
+}
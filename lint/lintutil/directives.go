@@ -0,0 +1,85 @@
+package lintutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"honnef.co/go/tools/lint"
+	"honnef.co/go/tools/lint/lintutil/format"
+)
+
+// printDirectives prints a report of all linter directives that
+// suppress problems, for auditing purposes. Directives are printed as
+// JSON objects if format is "json", and as text otherwise.
+func printDirectives(w io.Writer, ds []lint.Directive, formatter string) error {
+	sort.Slice(ds, func(i, j int) bool {
+		pi, pj := ds[i].Position, ds[j].Position
+		if pi.Filename != pj.Filename {
+			return pi.Filename < pj.Filename
+		}
+		return pi.Line < pj.Line
+	})
+
+	now := time.Now().UTC()
+	for i, d := range ds {
+		if i > 0 && d.Position == ds[i-1].Position {
+			// the same file may be part of several packages
+			continue
+		}
+		var until string
+		if !d.Until.IsZero() {
+			until = d.Until.Format("2006-01-02")
+		}
+		status := "unmatched"
+		if d.Expired(now) {
+			status = "expired"
+		} else if d.Matched {
+			status = "matched"
+		}
+
+		if formatter == "json" {
+			type location struct {
+				File   string `json:"file"`
+				Line   int    `json:"line"`
+				Column int    `json:"column"`
+			}
+			jd := struct {
+				Directive string   `json:"directive"`
+				Location  location `json:"location"`
+				Checks    []string `json:"checks"`
+				Reason    string   `json:"reason"`
+				Until     string   `json:"until,omitempty"`
+				Status    string   `json:"status"`
+			}{
+				Directive: d.Command,
+				Location: location{
+					File:   d.Position.Filename,
+					Line:   d.Position.Line,
+					Column: d.Position.Column,
+				},
+				Checks: d.Checks,
+				Reason: d.Reason,
+				Until:  until,
+				Status: status,
+			}
+			if err := json.NewEncoder(w).Encode(jd); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if until != "" {
+			status = "until " + until + ", " + status
+		}
+		_, err := fmt.Fprintf(w, "%s: %s %s (%s): %s\n",
+			format.RelativePosition(d.Position), d.Command, strings.Join(d.Checks, ","), status, d.Reason)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package lintutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"honnef.co/go/tools/lint"
+)
+
+// docURL returns a link to the online documentation of check, or the
+// empty string if the check isn't documented.
+func docURL(docs map[string]*lint.Documentation) func(check string) string {
+	return func(check string) string {
+		if docs[check] == nil {
+			return ""
+		}
+		return "https://staticcheck.io/docs/checks#" + check
+	}
+}
+
+// checkDocs returns the documentation of all checks, keyed by check ID.
+func checkDocs(cs []lint.Checker) map[string]*lint.Documentation {
+	docs := map[string]*lint.Documentation{}
+	for _, c := range cs {
+		for _, check := range c.Checks() {
+			if check.Doc != nil {
+				docs[check.ID] = check.Doc
+			}
+		}
+	}
+	return docs
+}
+
+// explain prints the documentation of check.
+func explain(w io.Writer, cs []lint.Checker, check string) error {
+	doc, ok := checkDocs(cs)[strings.ToUpper(check)]
+	if !ok {
+		return fmt.Errorf("no documentation for check %s", check)
+	}
+	_, err := fmt.Fprintf(w, "%s: %s", strings.ToUpper(check), doc)
+	return err
+}
+
+// listChecks prints an overview of all documented checks. Checks are
+// printed as JSON objects if format is "json", and as a table
+// otherwise.
+func listChecks(w io.Writer, cs []lint.Checker, formatter string) error {
+	docs := checkDocs(cs)
+	ids := make([]string, 0, len(docs))
+	for id := range docs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	if formatter == "json" {
+		enc := json.NewEncoder(w)
+		for _, id := range ids {
+			doc := docs[id]
+			jc := struct {
+				Check    string `json:"check"`
+				Title    string `json:"title"`
+				Category string `json:"category"`
+				Since    string `json:"since"`
+				Default  bool   `json:"default"`
+				Text     string `json:"text,omitempty"`
+			}{
+				Check:    id,
+				Title:    doc.Title,
+				Category: doc.Category,
+				Since:    doc.Since,
+				Default:  !doc.NonDefault,
+				Text:     doc.Text,
+			}
+			if err := enc.Encode(jc); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, id := range ids {
+		doc := docs[id]
+		def := "yes"
+		if doc.NonDefault {
+			def = "no"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", id, doc.Since, def, doc.Title)
+	}
+	return tw.Flush()
+}
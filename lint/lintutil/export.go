@@ -0,0 +1,205 @@
+package lintutil
+
+import (
+	"context"
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/packages"
+	"honnef.co/go/tools/facts"
+	"honnef.co/go/tools/internal/cache"
+	"honnef.co/go/tools/lint"
+)
+
+// factsEntry is what we store per dependency when loading
+// dependencies from export data.
+type factsEntry struct {
+	Facts []facts.Serialized
+}
+
+// loadMode returns the mode to load the packages we lint with.
+func loadMode(opt *Options) packages.LoadMode {
+	if opt.ExportData {
+		return packages.LoadSyntax
+	}
+	return packages.LoadAllSyntax
+}
+
+// factsRecorder is a pseudo checker that records the facts about all
+// initial packages, so that they can be stored in the cache.
+type factsRecorder struct {
+	facts map[string][]facts.Serialized
+}
+
+func (*factsRecorder) Name() string         { return "facts" }
+func (*factsRecorder) Prefix() string       { return "" }
+func (*factsRecorder) Checks() []lint.Check { return nil }
+
+func (fr *factsRecorder) Init(prog *lint.Program) {
+	fr.facts = map[string][]facts.Serialized{}
+	for _, pkg := range prog.InitialPackages {
+		facts.Compute(prog, pkg, func(obj types.Object, fact lint.Fact) {
+			if s, ok := facts.Serialize(obj, fact); ok {
+				fr.facts[pkg.ID] = append(fr.facts[pkg.ID], s)
+			}
+		})
+	}
+}
+
+// dependencyFacts returns the facts about the dependencies of initial,
+// which have been loaded from export data. Facts are taken from c if
+// possible. The remaining dependencies are loaded from source, one
+// level of the import graph at a time, so that we never hold more
+// than one level's syntax and SSA in memory. If most dependencies
+// are missing, as with a cold or no cache, that costs more than it
+// saves, and they are loaded from source all at once instead. Their
+// facts are stored in c, if it is non-nil.
+func dependencyFacts(ctx context.Context, c *cache.Cache, k *keyer, conf *packages.Config, initial []*packages.Package) (*facts.Store, error) {
+	isInitial := map[*packages.Package]bool{}
+	for _, pkg := range initial {
+		isInitial[pkg] = true
+	}
+	var deps []*packages.Package
+	packages.Visit(initial, nil, func(pkg *packages.Package) {
+		if !isInitial[pkg] && pkg.Types != nil {
+			deps = append(deps, pkg)
+		}
+	})
+
+	// byPath holds the facts of dependencies, by import path. We
+	// look facts up by path, because the packages we load from source
+	// are distinct from the ones we're linting.
+	byPath := map[string][]facts.Serialized{}
+	keys := map[string]cache.Key{}
+	missing := map[string]*packages.Package{}
+	withFacts := 0
+	for _, dep := range deps {
+		if len(dep.GoFiles) == 0 {
+			// unsafe and packages consisting entirely of assembly
+			// don't have any facts.
+			continue
+		}
+		withFacts++
+		missing[dep.PkgPath] = dep
+		if c == nil {
+			continue
+		}
+		key, err := k.factsKey(dep)
+		if err != nil {
+			return nil, err
+		}
+		var e factsEntry
+		if c.Get(key, &e) {
+			byPath[dep.PkgPath] = e.Facts
+			delete(missing, dep.PkgPath)
+			continue
+		}
+		keys[dep.PkgPath] = key
+	}
+
+	// compute loads pkgs from source in mode and computes their
+	// facts.
+	compute := func(mode packages.LoadMode, pkgs []string) error {
+		lconf := *conf
+		lconf.Mode = mode
+		lconf.Tests = false
+		loaded, err := packages.Load(&lconf, pkgs...)
+		if err != nil {
+			return err
+		}
+		var working []*packages.Package
+		for _, pkg := range loaded {
+			if !pkg.IllTyped {
+				working = append(working, pkg)
+			}
+		}
+		if len(working) == 0 {
+			return nil
+		}
+
+		levelStore := facts.NewStore()
+		packages.Visit(working, nil, func(pkg *packages.Package) {
+			if pkg.Types != nil {
+				levelStore.Add(pkg.Types, byPath[pkg.PkgPath])
+			}
+		})
+		fr := &factsRecorder{}
+		l := &lint.Linter{
+			Checkers:         []lint.Checker{fr},
+			ImportObjectFact: levelStore.Import,
+		}
+		if _, err := l.Lint(ctx, working, nil); err != nil {
+			return err
+		}
+
+		for _, pkg := range working {
+			fs := fr.facts[pkg.ID]
+			byPath[pkg.PkgPath] = fs
+			if key, ok := keys[pkg.PkgPath]; ok {
+				// Failing to write to the cache only costs us
+				// performance.
+				_ = c.Put(key, factsEntry{Facts: fs})
+			}
+		}
+		return nil
+	}
+	if 2*len(missing) > withFacts {
+		paths := make([]string, 0, len(missing))
+		for path := range missing {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		if err := compute(packages.LoadAllSyntax, paths); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, level := range importLevels(missing) {
+			if err := compute(packages.LoadSyntax, level); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	store := facts.NewStore()
+	for _, dep := range deps {
+		store.Add(dep.Types, byPath[dep.PkgPath])
+	}
+	return store, nil
+}
+
+// importLevels groups pkgs by their depth in the import graph, so
+// that each group only imports packages of earlier groups. Only
+// imports between packages in pkgs are considered.
+func importLevels(pkgs map[string]*packages.Package) [][]string {
+	depth := map[string]int{}
+	var visit func(pkg *packages.Package) int
+	visit = func(pkg *packages.Package) int {
+		if d, ok := depth[pkg.PkgPath]; ok {
+			return d
+		}
+		d := 0
+		for _, imp := range pkg.Imports {
+			if _, ok := pkgs[imp.PkgPath]; !ok {
+				continue
+			}
+			if id := visit(imp) + 1; id > d {
+				d = id
+			}
+		}
+		depth[pkg.PkgPath] = d
+		return d
+	}
+
+	var levels [][]string
+	for _, pkg := range pkgs {
+		d := visit(pkg)
+		for len(levels) <= d {
+			levels = append(levels, nil)
+		}
+		levels[d] = append(levels[d], pkg.PkgPath)
+	}
+	for _, level := range levels {
+		sort.Strings(level)
+	}
+	return levels
+}
@@ -0,0 +1,125 @@
+package lintutil
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+	"honnef.co/go/tools/internal/cache"
+	"honnef.co/go/tools/lint"
+)
+
+func TestDependencyFacts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, data string) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.mod", "module example.com\n")
+	write("c/c.go", `package c
+
+func Inc(x int) int { return x + 1 }
+`)
+	writeA := func(extra string) {
+		write("a/a.go", `package a
+
+import "example.com/c"
+
+func Add(x int) int { return c.Inc(x) + 1 }
+
+func Loop() {
+	for {
+	}
+}
+
+// Deprecated: Use Add instead.
+func Old() {}
+`+extra)
+	}
+	writeA("")
+	write("b/b.go", `package b
+
+import "example.com/a"
+
+var X = a.Add(1)
+`)
+
+	c, err := cache.Open(filepath.Join(dir, "cache"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := &packages.Config{Dir: dir}
+
+	// check compares the facts about the dependencies of b that
+	// dependencyFacts restores for export data with those computed
+	// from source.
+	check := func() {
+		conf.Mode = packages.LoadAllSyntax
+		src, err := packages.Load(conf, "./a", "./c")
+		if err != nil {
+			t.Fatal(err)
+		}
+		fr := &factsRecorder{}
+		l := &lint.Linter{Checkers: []lint.Checker{fr}}
+		if _, err := l.Lint(context.Background(), src, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		conf.Mode = packages.LoadSyntax
+		initial, err := packages.Load(conf, "./b")
+		if err != nil {
+			t.Fatal(err)
+		}
+		store, err := dependencyFacts(context.Background(), c, newKeyer(nil, &Options{ExportData: true}), conf, initial)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		n := 0
+		packages.Visit(initial, nil, func(pkg *packages.Package) {
+			for _, spkg := range src {
+				if spkg.PkgPath != pkg.PkgPath {
+					continue
+				}
+				for _, want := range fr.facts[spkg.ID] {
+					obj, err := want.Object(pkg.Types)
+					if err != nil {
+						t.Errorf("%s: %s", want.Path, err)
+						continue
+					}
+					got := reflect.New(reflect.TypeOf(want.Fact).Elem()).Interface().(lint.Fact)
+					if !store.Import(obj, got) || !reflect.DeepEqual(got, want.Fact) {
+						t.Errorf("%s: got fact %#v, want %#v", obj, got, want.Fact)
+					}
+					n++
+				}
+			}
+		})
+		// Add, Inc, Loop and Old each have at least one fact.
+		if n < 4 {
+			t.Errorf("compared %d facts, want at least 4", n)
+		}
+	}
+
+	// With a cold cache, all dependencies are loaded from source at
+	// once.
+	check()
+	// With a warm cache, no dependency is loaded.
+	check()
+	// With one of two dependencies changed, it is loaded by itself.
+	writeA("\nfunc Pure(x int) int { return x * 2 }\n")
+	check()
+}
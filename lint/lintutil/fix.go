@@ -0,0 +1,155 @@
+package lintutil
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sort"
+
+	"honnef.co/go/tools/lint"
+)
+
+// selectFixes picks the problems whose suggested fixes can be applied
+// together. Fixes are considered in order; a fix that conflicts with
+// an already selected one is skipped entirely, so that we never apply
+// half of a fix. It returns the edits grouped by file and, for each
+// problem, whether its fix was selected.
+func selectFixes(ps []lint.Problem) (map[string][]lint.Edit, []bool) {
+	edits := map[string][]lint.Edit{}
+	selected := make([]bool, len(ps))
+	for i, p := range ps {
+		if len(p.Edits) == 0 || p.Severity == lint.Ignored {
+			continue
+		}
+		ok := true
+	check:
+		for _, e := range p.Edits {
+			for _, oe := range edits[e.Start.Filename] {
+				if e.Overlaps(oe) {
+					ok = false
+					break check
+				}
+			}
+		}
+		if !ok {
+			continue
+		}
+		for _, e := range p.Edits {
+			edits[e.Start.Filename] = append(edits[e.Start.Filename], e)
+		}
+		selected[i] = true
+	}
+	return edits, selected
+}
+
+// applyFixes applies all non-conflicting fixes in ps. If w is nil,
+// the modified files are written back to disk; otherwise a unified
+// diff of the changes is written to w. It returns the problems whose
+// fixes weren't applied.
+func applyFixes(ps []lint.Problem, w io.Writer) ([]lint.Problem, error) {
+	edits, selected := selectFixes(ps)
+
+	files := make([]string, 0, len(edits))
+	for file := range edits {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		res, err := lint.ApplyEdits(src, edits[file])
+		if err != nil {
+			return nil, fmt.Errorf("couldn't fix %s: %s", file, err)
+		}
+		if w == nil {
+			fi, err := os.Stat(file)
+			if err != nil {
+				return nil, err
+			}
+			if err := ioutil.WriteFile(file, res, fi.Mode()); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		d, err := diff(src, res, file)
+		if err != nil {
+			return nil, fmt.Errorf("computing diff: %s", err)
+		}
+		if _, err := w.Write(d); err != nil {
+			return nil, err
+		}
+	}
+
+	var out []lint.Problem
+	for i, p := range ps {
+		if !selected[i] {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func writeTempFile(prefix string, data []byte) (string, error) {
+	file, err := ioutil.TempFile("", prefix)
+	if err != nil {
+		return "", err
+	}
+	_, err = file.Write(data)
+	if err1 := file.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// diff computes a unified diff between b1 and b2 using the system's
+// diff tool, the same way gofmt -d does.
+func diff(b1, b2 []byte, filename string) ([]byte, error) {
+	f1, err := writeTempFile("staticcheck", b1)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f1)
+
+	f2, err := writeTempFile("staticcheck", b2)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f2)
+
+	data, err := exec.Command("diff", "-u", f1, f2).CombinedOutput()
+	if len(data) > 0 {
+		// diff exits with a non-zero status when the files don't
+		// match. Ignore that failure as long as we get output.
+		return replaceTempFilename(data, filename)
+	}
+	return data, err
+}
+
+// replaceTempFilename replaces the temporary filenames in the diff
+// header with the name of the original file.
+func replaceTempFilename(diff []byte, filename string) ([]byte, error) {
+	bs := bytes.SplitN(diff, []byte{'\n'}, 3)
+	if len(bs) < 3 {
+		return nil, fmt.Errorf("got unexpected diff for %s", filename)
+	}
+	var t0, t1 []byte
+	if i := bytes.LastIndexByte(bs[0], '\t'); i != -1 {
+		t0 = bs[0][i:]
+	}
+	if i := bytes.LastIndexByte(bs[1], '\t'); i != -1 {
+		t1 = bs[1][i:]
+	}
+	bs[0] = []byte(fmt.Sprintf("--- %s%s", filename+".orig", t0))
+	bs[1] = []byte(fmt.Sprintf("+++ %s%s", filename, t1))
+	return bytes.Join(bs, []byte{'\n'}), nil
+}
@@ -0,0 +1,88 @@
+package lintutil
+
+import (
+	"bytes"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"honnef.co/go/tools/lint"
+)
+
+func TestApplyFixes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "a.go")
+	const src = "package a\n\nvar x = 1\n"
+	write := func() {
+		if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	edit := func(start, end int, text string) lint.Edit {
+		return lint.Edit{
+			Start:   token.Position{Filename: path, Offset: start},
+			End:     token.Position{Filename: path, Offset: end},
+			NewText: text,
+		}
+	}
+	problem := func(check string, edits ...lint.Edit) lint.Problem {
+		return lint.Problem{Position: token.Position{Filename: path, Line: 3, Column: 5}, Check: check, Edits: edits}
+	}
+	ps := []lint.Problem{
+		// renames x to y
+		problem("A", edit(15, 16, "y"), edit(19, 20, "2")),
+		// overlaps with the first fix and is skipped entirely
+		problem("B", edit(11, 16, "const x"), edit(0, 9, "package b")),
+		// has the same edit as the first fix, which is applied once
+		problem("C", edit(19, 20, "2")),
+		// has no fix
+		problem("D"),
+	}
+
+	write()
+	remaining, err := applyFixes(ps, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "package a\n\nvar y = 2\n"; string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	var checks []string
+	for _, p := range remaining {
+		checks = append(checks, p.Check)
+	}
+	if got := strings.Join(checks, ","); got != "B,D" {
+		t.Errorf("got remaining problems %s, want B,D", got)
+	}
+
+	if _, err := exec.LookPath("diff"); err != nil {
+		t.Skip("diff is not installed")
+	}
+	write()
+	var buf bytes.Buffer
+	if _, err := applyFixes(ps, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ioutil.ReadFile(path); err != nil || string(got) != src {
+		t.Errorf("printing a diff modified the file: %q, %v", got, err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	if len(lines) < 2 || !strings.HasPrefix(lines[0], "--- "+path+".orig") || !strings.HasPrefix(lines[1], "+++ "+path) {
+		t.Fatalf("got diff\n%s\nwant a header naming %s", buf.String(), path)
+	}
+	if want := "-var x = 1\n+var y = 2\n"; !strings.Contains(buf.String(), want) {
+		t.Errorf("got diff\n%s\nwant it to contain\n%s", buf.String(), want)
+	}
+}
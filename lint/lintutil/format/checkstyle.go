@@ -0,0 +1,95 @@
+package format
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"honnef.co/go/tools/lint"
+)
+
+// Checkstyle formats problems as a Checkstyle XML report, grouping
+// them per file. Problems are buffered and written by Stats.
+type Checkstyle struct {
+	W io.Writer
+	// Tool is the name of the linter, which prefixes the checks in
+	// the source attributes.
+	Tool string
+
+	files []*checkstyleFile
+	index map[string]int
+}
+
+type checkstyleReport struct {
+	XMLName xml.Name          `xml:"checkstyle"`
+	Version string            `xml:"version,attr"`
+	Files   []*checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Column   int    `xml:"column,attr"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+func checkstyleSeverity(s lint.Severity) string {
+	switch s {
+	case lint.Warning:
+		return "warning"
+	case lint.Info, lint.Hint:
+		return "info"
+	case lint.Ignored:
+		return "ignore"
+	}
+	return "error"
+}
+
+func (o *Checkstyle) Format(p lint.Problem) {
+	if o.index == nil {
+		o.index = map[string]int{}
+	}
+	name := p.Position.Filename
+	if name == "" {
+		name = "-"
+	}
+	i, ok := o.index[name]
+	if !ok {
+		i = len(o.files)
+		o.index[name] = i
+		o.files = append(o.files, &checkstyleFile{Name: name})
+	}
+	source := p.Check
+	if source == "" {
+		source = p.Checker
+	}
+	if o.Tool != "" {
+		source = o.Tool + "." + source
+	}
+	o.files[i].Errors = append(o.files[i].Errors, checkstyleError{
+		Line:     p.Position.Line,
+		Column:   p.Position.Column,
+		Severity: checkstyleSeverity(p.Severity),
+		Message:  p.Text,
+		Source:   source,
+	})
+}
+
+func (o *Checkstyle) Stats(total, errors, warnings int) {
+	writeXML(o.W, checkstyleReport{Version: "5.0", Files: o.files})
+}
+
+// writeXML writes v as an indented XML document.
+func writeXML(w io.Writer, v interface{}) {
+	b, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "%s%s\n", xml.Header, b)
+}
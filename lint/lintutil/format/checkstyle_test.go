@@ -0,0 +1,11 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCheckstyle(t *testing.T) {
+	var buf bytes.Buffer
+	golden(t, "checkstyle", run(&Checkstyle{W: &buf, Tool: "staticcheck"}, &buf, testProblems(t)))
+}
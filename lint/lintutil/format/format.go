@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"text/tabwriter"
+	"time"
 
 	"honnef.co/go/tools/lint"
 )
@@ -24,7 +25,9 @@ func shortPath(path string) string {
 	return path
 }
 
-func relativePositionString(pos token.Position) string {
+// RelativePosition formats pos, using a path relative to the current
+// directory if that is shorter.
+func RelativePosition(pos token.Position) string {
 	s := shortPath(pos.Filename)
 	if pos.IsValid() {
 		if s != "" {
@@ -46,16 +49,34 @@ type Formatter interface {
 	Format(p lint.Problem)
 }
 
+// docURL returns the documentation URL of p's check, if any.
+func docURL(fn func(check string) string, p lint.Problem) string {
+	if fn == nil || p.Check == "" {
+		return ""
+	}
+	return fn(p.Check)
+}
+
 type Text struct {
 	W io.Writer
+	// DocURL, if set, returns a link to the documentation of a
+	// check, which is printed after each problem.
+	DocURL func(check string) string
 }
 
 func (o Text) Format(p lint.Problem) {
-	fmt.Fprintf(o.W, "%v: %s\n", relativePositionString(p.Position), p.String())
+	if url := docURL(o.DocURL, p); url != "" {
+		fmt.Fprintf(o.W, "%v: %s (see %s)\n", RelativePosition(p.Position), p.String(), url)
+		return
+	}
+	fmt.Fprintf(o.W, "%v: %s\n", RelativePosition(p.Position), p.String())
 }
 
 type JSON struct {
 	W io.Writer
+	// DocURL, if set, returns a link to the documentation of a
+	// check, which is included in the "documentation" field.
+	DocURL func(check string) string
 }
 
 func severity(s lint.Severity) string {
@@ -66,36 +87,200 @@ func severity(s lint.Severity) string {
 		return "warning"
 	case lint.Ignored:
 		return "ignored"
+	case lint.Info:
+		return "info"
+	case lint.Hint:
+		return "hint"
 	}
 	return ""
 }
 
+// JSONProblem is the representation of a problem in the JSON format,
+// one object per line.
+//
+// The JSON formatter only fills in the code, severity, location,
+// message and documentation. The remaining fields are optional and
+// are used by the daemon, whose clients need to reconstruct problems
+// in full, for example to apply their fixes.
+type JSONProblem struct {
+	Code     string       `json:"code"`
+	Severity string       `json:"severity,omitempty"`
+	Location JSONLocation `json:"location"`
+	Message  string       `json:"message"`
+	Doc      string       `json:"documentation,omitempty"`
+
+	// End is the end of the problem's range.
+	End *JSONLocation `json:"end,omitempty"`
+	// Checker is the name of the checker that reported the problem.
+	Checker string `json:"checker,omitempty"`
+	// Package is the ID of the package the problem was found in.
+	Package string        `json:"package,omitempty"`
+	Edits   []JSONEdit    `json:"edits,omitempty"`
+	Related []JSONRelated `json:"related,omitempty"`
+	// Suppression is the directive that suppressed the problem, if
+	// its severity is "ignored".
+	Suppression *JSONDirective `json:"suppression,omitempty"`
+}
+
+// A JSONLocation is a position in a file. Offset is the byte offset
+// in the file, and is only included where edits depend on it.
+type JSONLocation struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Offset int    `json:"offset,omitempty"`
+}
+
+type JSONEdit struct {
+	Start   JSONLocation `json:"start"`
+	End     JSONLocation `json:"end"`
+	NewText string       `json:"new_text"`
+}
+
+type JSONRelated struct {
+	Location JSONLocation `json:"location"`
+	Message  string       `json:"message"`
+}
+
+// A JSONDirective is a linter directive. Until, the day after which
+// the directive expires, has the format YYYY-MM-DD.
+type JSONDirective struct {
+	Command  string       `json:"directive"`
+	Location JSONLocation `json:"location"`
+	Checks   []string     `json:"checks"`
+	Reason   string       `json:"reason"`
+	Until    string       `json:"until,omitempty"`
+	Matched  bool         `json:"matched"`
+}
+
+func jsonLocation(pos token.Position) JSONLocation {
+	return JSONLocation{
+		File:   pos.Filename,
+		Line:   pos.Line,
+		Column: pos.Column,
+		Offset: pos.Offset,
+	}
+}
+
+func (l JSONLocation) position() token.Position {
+	return token.Position{Filename: l.File, Line: l.Line, Column: l.Column, Offset: l.Offset}
+}
+
+// NewJSONProblem returns the representation of p, including all
+// optional fields.
+func NewJSONProblem(p lint.Problem) JSONProblem {
+	jp := JSONProblem{
+		Code:     p.Check,
+		Severity: severity(p.Severity),
+		Location: jsonLocation(p.Position),
+		Message:  p.Text,
+		Checker:  p.Checker,
+	}
+	if p.End.IsValid() {
+		end := jsonLocation(p.End)
+		jp.End = &end
+	}
+	if p.Package != nil && p.Package.Package != nil {
+		jp.Package = p.Package.ID
+	}
+	for _, e := range p.Edits {
+		jp.Edits = append(jp.Edits, JSONEdit{Start: jsonLocation(e.Start), End: jsonLocation(e.End), NewText: e.NewText})
+	}
+	for _, r := range p.Related {
+		jp.Related = append(jp.Related, JSONRelated{Location: jsonLocation(r.Position), Message: r.Message})
+	}
+	if p.Suppression != nil {
+		d := NewJSONDirective(*p.Suppression)
+		jp.Suppression = &d
+	}
+	return jp
+}
+
+// Problem converts jp back to a problem. The problem's package is
+// left unset; jp.Package identifies it.
+func (jp JSONProblem) Problem() lint.Problem {
+	p := lint.Problem{
+		Position: jp.Location.position(),
+		Text:     jp.Message,
+		Check:    jp.Code,
+		Checker:  jp.Checker,
+	}
+	switch jp.Severity {
+	case "warning":
+		p.Severity = lint.Warning
+	case "ignored":
+		p.Severity = lint.Ignored
+	case "info":
+		p.Severity = lint.Info
+	case "hint":
+		p.Severity = lint.Hint
+	}
+	if jp.End != nil {
+		p.End = jp.End.position()
+	}
+	for _, e := range jp.Edits {
+		p.Edits = append(p.Edits, lint.Edit{Start: e.Start.position(), End: e.End.position(), NewText: e.NewText})
+	}
+	for _, r := range jp.Related {
+		p.Related = append(p.Related, lint.Related{Position: r.Location.position(), Message: r.Message})
+	}
+	if jp.Suppression != nil {
+		d := jp.Suppression.Directive()
+		p.Suppression = &d
+	}
+	return p
+}
+
+func NewJSONDirective(d lint.Directive) JSONDirective {
+	jd := JSONDirective{
+		Command:  d.Command,
+		Location: jsonLocation(d.Position),
+		Checks:   d.Checks,
+		Reason:   d.Reason,
+		Matched:  d.Matched,
+	}
+	if !d.Until.IsZero() {
+		jd.Until = d.Until.Format("2006-01-02")
+	}
+	return jd
+}
+
+// Directive converts jd back to a directive. Malformed expiry dates
+// are ignored.
+func (jd JSONDirective) Directive() lint.Directive {
+	d := lint.Directive{
+		Command:  jd.Command,
+		Position: jd.Location.position(),
+		Checks:   jd.Checks,
+		Reason:   jd.Reason,
+		Matched:  jd.Matched,
+	}
+	if jd.Until != "" {
+		d.Until, _ = time.Parse("2006-01-02", jd.Until)
+	}
+	return d
+}
+
 func (o JSON) Format(p lint.Problem) {
-	type location struct {
-		File   string `json:"file"`
-		Line   int    `json:"line"`
-		Column int    `json:"column"`
-	}
-	jp := struct {
-		Code     string   `json:"code"`
-		Severity string   `json:"severity,omitempty"`
-		Location location `json:"location"`
-		Message  string   `json:"message"`
-	}{
+	jp := JSONProblem{
 		Code:     p.Check,
 		Severity: severity(p.Severity),
-		Location: location{
+		Location: JSONLocation{
 			File:   p.Position.Filename,
 			Line:   p.Position.Line,
 			Column: p.Position.Column,
 		},
 		Message: p.Text,
+		Doc:     docURL(o.DocURL, p),
 	}
 	_ = json.NewEncoder(o.W).Encode(jp)
 }
 
 type Stylish struct {
 	W io.Writer
+	// DocURL, if set, returns a link to the documentation of a
+	// check, which is printed in an additional column.
+	DocURL func(check string) string
 
 	prevFile string
 	tw       *tabwriter.Writer
@@ -115,6 +300,10 @@ func (o *Stylish) Format(p lint.Problem) {
 		o.prevFile = p.Position.Filename
 		o.tw = tabwriter.NewWriter(o.W, 0, 4, 2, ' ', 0)
 	}
+	if url := docURL(o.DocURL, p); url != "" {
+		fmt.Fprintf(o.tw, "  (%d, %d)\t%s\t%s\t%s\n", p.Position.Line, p.Position.Column, p.Check, p.Text, url)
+		return
+	}
 	fmt.Fprintf(o.tw, "  (%d, %d)\t%s\t%s\n", p.Position.Line, p.Position.Column, p.Check, p.Text)
 }
 
@@ -0,0 +1,171 @@
+package format
+
+import (
+	"bytes"
+	"flag"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+	"honnef.co/go/tools/lint"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// testProblems returns problems in testdata/p.go, covering ranges on
+// lines with tabs and multibyte characters, fixes, related positions,
+// suppressed problems and problems without a position.
+func testProblems(t *testing.T) []lint.Problem {
+	path, err := filepath.Abs(filepath.Join("testdata", "p.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(b)
+	// pos returns the position of the n'th byte after the first
+	// occurrence of s.
+	pos := func(s string, n int) token.Position {
+		off := strings.Index(src, s)
+		if off == -1 {
+			t.Fatalf("%q not found in %s", s, path)
+		}
+		off += n
+		line := 1 + strings.Count(src[:off], "\n")
+		col := off - strings.LastIndex(src[:off], "\n")
+		return token.Position{Filename: path, Offset: off, Line: line, Column: col}
+	}
+	pkg := &lint.Pkg{Package: &packages.Package{ID: "example.com/p", PkgPath: "example.com/p"}}
+	lit := `"héllo, "`
+	return []lint.Problem{
+		{
+			Position: pos(lit, 0),
+			End:      pos(lit, len(lit)),
+			Text:     "string literal contains the Unicode character U+00E9",
+			Check:    "ST1018",
+			Checker:  "stylecheck",
+			Package:  pkg,
+			Severity: lint.Warning,
+		},
+		{
+			Position: pos("\tmsg = msg", 1),
+			End:      pos("\tmsg = msg", len("\tmsg = msg")),
+			Text:     "self-assignment of msg to msg",
+			Check:    "SA4018",
+			Checker:  "staticcheck",
+			Package:  pkg,
+			Severity: lint.Error,
+			Edits: []lint.Edit{{
+				Start: pos("\tmsg = msg\n", 0),
+				End:   pos("\tmsg = msg\n", len("\tmsg = msg\n")),
+			}},
+			Related: []lint.Related{{Position: pos("msg :=", 0), Message: "msg is declared here"}},
+		},
+		{
+			Position: pos("Greet_user", 0),
+			End:      pos("Greet_user", len("Greet_user")),
+			Text:     "should not use underscores in Go names; func Greet_user should be GreetUser",
+			Check:    "ST1003",
+			Checker:  "stylecheck",
+			Package:  pkg,
+			Severity: lint.Ignored,
+			Suppression: &lint.Directive{
+				Command:  "ignore",
+				Position: pos("//lint:ignore", 0),
+				Checks:   []string{"ST1003"},
+				Reason:   "matches the protocol",
+				Matched:  true,
+			},
+		},
+		{
+			Position: pos("unused()", 0),
+			End:      pos("unused()", len("unused")),
+			Text:     "func unused is unused",
+			Check:    "U1000",
+			Checker:  "unused",
+			Package:  pkg,
+			Severity: lint.Warning,
+		},
+		{
+			Text:     "could not analyze dependency example.com/q",
+			Checker:  "compile",
+			Severity: lint.Error,
+		},
+	}
+}
+
+// testDocs documents some of the checks of testProblems.
+var testDocs = map[string]*lint.Documentation{
+	"SA4018": {
+		Title:    "Self-assignment of variables",
+		Since:    "2019.2",
+		Category: "Code that isn't really doing anything",
+	},
+	"ST1003": {
+		Title:      "Poorly chosen identifier",
+		Text:       "Identifiers, such as variable and package names, follow certain\nrules.",
+		Since:      "2019.1",
+		NonDefault: true,
+		Category:   "Stylistic issues",
+	},
+}
+
+func testDocURL(check string) string {
+	return "https://staticcheck.io/docs/checks#" + check
+}
+
+// run formats ps with f, the way ProcessFlagSet does, and returns the
+// output written to w.
+func run(f Formatter, w *bytes.Buffer, ps []lint.Problem) []byte {
+	var errors, warnings int
+	for _, p := range ps {
+		f.Format(p)
+		switch p.Severity {
+		case lint.Error:
+			errors++
+		case lint.Warning:
+			warnings++
+		}
+	}
+	if f, ok := f.(Statter); ok {
+		f.Stats(len(ps), errors, warnings)
+	}
+	return w.Bytes()
+}
+
+// golden compares got with the file testdata/name.golden, or updates
+// the file if the -update flag is set. Occurrences of the current
+// directory are replaced with $CWD, so that the files don't depend on
+// where the repository is.
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = bytes.Replace(got, []byte(cwd), []byte("$CWD"), -1)
+	if slash := filepath.ToSlash(cwd); slash != cwd {
+		got = bytes.Replace(got, []byte(slash), []byte("$CWD"), -1)
+	}
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := ioutil.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output doesn't match %s; got:\n%s", path, got)
+	}
+}
@@ -0,0 +1,309 @@
+package format
+
+import (
+	"encoding/json"
+	"html/template"
+	"io"
+	"sort"
+	"time"
+
+	"honnef.co/go/tools/lint"
+)
+
+// HTML formats problems as a self-contained HTML report, with
+// summaries per package, check and checker, and a table of all
+// problems that can be sorted and filtered in the browser. The
+// report doesn't load any external resources. Problems are buffered
+// and written by Stats.
+type HTML struct {
+	W io.Writer
+	// Tool is the name of the linter, which titles the report.
+	Tool string
+	// DocURL, if set, returns a link to the documentation of a
+	// check, which is linked from the check's name.
+	DocURL func(check string) string
+
+	problems []htmlProblem
+	source   sourceCache
+}
+
+type htmlProblem struct {
+	Package  string
+	File     string
+	Line     int
+	Column   int
+	Position string
+	Check    string
+	Checker  string
+	Severity string
+	Message  string
+	DocURL   string
+	Excerpt  []htmlLine
+	Related  []string
+}
+
+type htmlLine struct {
+	Number int
+	Text   string
+	// Marked is set for the line of the problem.
+	Marked bool
+}
+
+// htmlCount is a row of a summary table.
+type htmlCount struct {
+	Name     string `json:"name"`
+	Total    int    `json:"total"`
+	Errors   int    `json:"errors"`
+	Warnings int    `json:"warnings"`
+	Other    int    `json:"other"`
+}
+
+// excerptContext is the number of lines shown before and after the
+// line of a problem.
+const excerptContext = 2
+
+func (o *HTML) Format(p lint.Problem) {
+	if o.source == nil {
+		o.source = sourceCache{}
+	}
+	hp := htmlProblem{
+		Package:  "-",
+		File:     shortPath(p.Position.Filename),
+		Line:     p.Position.Line,
+		Column:   p.Position.Column,
+		Position: RelativePosition(p.Position),
+		Check:    p.Check,
+		Checker:  p.Checker,
+		Severity: severity(p.Severity),
+		Message:  p.Text,
+		DocURL:   docURL(o.DocURL, p),
+	}
+	if hp.Severity == "" {
+		hp.Severity = "error"
+	}
+	if hp.File == "" {
+		hp.File = "-"
+	}
+	if hp.Check == "" {
+		hp.Check = p.Checker
+	}
+	if p.Package != nil && p.Package.Package != nil {
+		hp.Package = p.Package.PkgPath
+	}
+	for n := p.Position.Line - excerptContext; n <= p.Position.Line+excerptContext; n++ {
+		if line, ok := o.source.line(p.Position.Filename, n); ok {
+			hp.Excerpt = append(hp.Excerpt, htmlLine{n, line, n == p.Position.Line})
+		}
+	}
+	for _, r := range p.Related {
+		hp.Related = append(hp.Related, RelativePosition(r.Position)+": "+r.Message)
+	}
+	o.problems = append(o.problems, hp)
+}
+
+// counts groups problems by key and counts them per severity.
+func (o *HTML) counts(key func(p htmlProblem) string) []htmlCount {
+	m := map[string]*htmlCount{}
+	for _, p := range o.problems {
+		k := key(p)
+		c, ok := m[k]
+		if !ok {
+			c = &htmlCount{Name: k}
+			m[k] = c
+		}
+		c.Total++
+		switch p.Severity {
+		case "error":
+			c.Errors++
+		case "warning":
+			c.Warnings++
+		default:
+			c.Other++
+		}
+	}
+	out := make([]htmlCount, 0, len(m))
+	for _, c := range m {
+		out = append(out, *c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Total != out[j].Total {
+			return out[i].Total > out[j].Total
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func (o *HTML) Stats(total, errors, warnings int) {
+	sort.SliceStable(o.problems, func(i, j int) bool {
+		pi, pj := o.problems[i], o.problems[j]
+		if pi.Package != pj.Package {
+			return pi.Package < pj.Package
+		}
+		if pi.File != pj.File {
+			return pi.File < pj.File
+		}
+		if pi.Line != pj.Line {
+			return pi.Line < pj.Line
+		}
+		return pi.Check < pj.Check
+	})
+	checkers := o.counts(func(p htmlProblem) string { return p.Checker })
+	// The totals are embedded as JSON, so that tools can track them
+	// over time without parsing the HTML.
+	totals, _ := json.Marshal(struct {
+		Total    int         `json:"total"`
+		Errors   int         `json:"errors"`
+		Warnings int         `json:"warnings"`
+		Checkers []htmlCount `json:"checkers"`
+	}{total, errors, warnings, checkers})
+
+	checks := o.counts(func(p htmlProblem) string { return p.Check })
+	var checkNames []string
+	for _, c := range checks {
+		checkNames = append(checkNames, c.Name)
+	}
+	sort.Strings(checkNames)
+
+	_ = htmlTemplate.Execute(o.W, map[string]interface{}{
+		"Tool":     o.Tool,
+		"Date":     time.Now().Format("2006-01-02 15:04"),
+		"Total":    total,
+		"Errors":   errors,
+		"Warnings": warnings,
+		"Totals":   template.JS(totals),
+		"Checkers": checkers,
+		"Packages": o.counts(func(p htmlProblem) string { return p.Package }),
+		"Files":    o.counts(func(p htmlProblem) string { return p.File }),
+		"Checks":   checks,
+		"Names":    checkNames,
+		"Problems": o.problems,
+	})
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	// dict builds a map from pairs of keys and values, for passing
+	// several values to a template.
+	"dict": func(kv ...interface{}) map[string]interface{} {
+		m := map[string]interface{}{}
+		for i := 0; i+1 < len(kv); i += 2 {
+			m[kv[i].(string)] = kv[i+1]
+		}
+		return m
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{with .Tool}}{{.}} {{end}}report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+h1 { margin-bottom: 0; }
+.date { color: #666; margin-top: 0.2em; }
+.totals span { display: inline-block; margin-right: 1.5em; font-size: 1.2em; }
+.summaries { display: flex; flex-wrap: wrap; gap: 2em; align-items: flex-start; }
+.summary { max-height: 20em; overflow-y: auto; }
+table { border-collapse: collapse; }
+th, td { padding: 0.2em 0.6em; text-align: left; vertical-align: top; border-bottom: 1px solid #ddd; }
+th { background: #f4f4f4; position: sticky; top: 0; }
+#problems th { cursor: pointer; user-select: none; }
+#problems th.asc::after { content: " \25b2"; }
+#problems th.desc::after { content: " \25bc"; }
+td.num { text-align: right; }
+.error { color: #c00; }
+.warning { color: #b60; }
+.info, .hint { color: #06c; }
+.ignored { color: #888; }
+details summary { cursor: pointer; }
+pre { background: #f8f8f8; padding: 0.4em; margin: 0.3em 0; overflow-x: auto; }
+pre .marked { background: #fdd; display: inline-block; width: 100%; }
+.filters { margin: 1em 0; }
+.filters input, .filters select { margin-right: 1em; }
+</style>
+</head>
+<body>
+<h1>{{with .Tool}}{{.}} {{end}}report</h1>
+<p class="date">Generated {{.Date}}</p>
+<p class="totals"><span>{{.Total}} problems</span><span class="error">{{.Errors}} errors</span><span class="warning">{{.Warnings}} warnings</span></p>
+<script type="application/json" id="totals">{{.Totals}}</script>
+
+<div class="summaries">
+{{define "counts"}}<table>
+<tr><th>{{.Title}}</th><th>total</th><th>errors</th><th>warnings</th><th>other</th></tr>
+{{range .Rows}}<tr><td>{{.Name}}</td><td class="num">{{.Total}}</td><td class="num">{{.Errors}}</td><td class="num">{{.Warnings}}</td><td class="num">{{.Other}}</td></tr>
+{{end}}</table>{{end}}
+<div class="summary"><h2>By checker</h2>{{template "counts" (dict "Title" "checker" "Rows" .Checkers)}}</div>
+<div class="summary"><h2>By package</h2>{{template "counts" (dict "Title" "package" "Rows" .Packages)}}</div>
+<div class="summary"><h2>By file</h2>{{template "counts" (dict "Title" "file" "Rows" .Files)}}</div>
+<div class="summary"><h2>By check</h2>{{template "counts" (dict "Title" "check" "Rows" .Checks)}}</div>
+</div>
+
+<h2>Problems</h2>
+<div class="filters">
+<input id="filter" type="search" placeholder="Filter by text">
+<select id="check"><option value="">All checks</option>{{range .Names}}<option>{{.}}</option>{{end}}</select>
+<select id="severity"><option value="">All severities</option><option>error</option><option>warning</option><option>info</option><option>hint</option><option>ignored</option></select>
+<span id="shown"></span>
+</div>
+<table id="problems">
+<thead><tr><th data-type="text">package</th><th data-type="text">position</th><th data-type="text">check</th><th data-type="text">severity</th><th data-type="text">message</th></tr></thead>
+<tbody>
+{{range .Problems}}<tr data-check="{{.Check}}" data-severity="{{.Severity}}">
+<td>{{.Package}}</td>
+<td data-sort="{{.File}}:{{printf "%08d" .Line}}:{{printf "%08d" .Column}}">{{.Position}}</td>
+<td>{{if .DocURL}}<a href="{{.DocURL}}">{{.Check}}</a>{{else}}{{.Check}}{{end}}</td>
+<td class="{{.Severity}}">{{.Severity}}</td>
+<td>{{if or .Excerpt .Related}}<details><summary>{{.Message}}</summary>{{if .Excerpt}}<pre>{{range .Excerpt}}<span{{if .Marked}} class="marked"{{end}}>{{printf "%5d" .Number}}  {{.Text}}</span>
+{{end}}</pre>{{end}}{{range .Related}}<div>{{.}}</div>{{end}}</details>{{else}}{{.Message}}{{end}}</td>
+</tr>
+{{end}}</tbody>
+</table>
+
+<script>
+(function() {
+	var table = document.getElementById("problems");
+	var body = table.tBodies[0];
+	var rows = Array.prototype.slice.call(body.rows);
+	var filter = document.getElementById("filter");
+	var check = document.getElementById("check");
+	var severity = document.getElementById("severity");
+	var shown = document.getElementById("shown");
+
+	function update() {
+		var text = filter.value.toLowerCase();
+		var n = 0;
+		rows.forEach(function(row) {
+			var ok = (!text || row.textContent.toLowerCase().indexOf(text) >= 0) &&
+				(!check.value || row.dataset.check === check.value) &&
+				(!severity.value || row.dataset.severity === severity.value);
+			row.style.display = ok ? "" : "none";
+			if (ok) n++;
+		});
+		shown.textContent = n + " of " + rows.length + " problems shown";
+	}
+	[filter, check, severity].forEach(function(el) { el.addEventListener("input", update); });
+
+	function key(row, i) {
+		var cell = row.cells[i];
+		return cell.dataset.sort || cell.textContent;
+	}
+	Array.prototype.forEach.call(table.tHead.rows[0].cells, function(th, i) {
+		th.addEventListener("click", function() {
+			var asc = !th.classList.contains("asc");
+			Array.prototype.forEach.call(table.tHead.rows[0].cells, function(other) {
+				other.classList.remove("asc", "desc");
+			});
+			th.classList.add(asc ? "asc" : "desc");
+			rows.sort(function(a, b) {
+				var c = key(a, i).localeCompare(key(b, i));
+				return asc ? c : -c;
+			});
+			rows.forEach(function(row) { body.appendChild(row); });
+		});
+	});
+	update();
+})();
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,18 @@
+package format
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+)
+
+func TestHTML(t *testing.T) {
+	var buf bytes.Buffer
+	out := run(&HTML{W: &buf, Tool: "staticcheck", DocURL: testDocURL}, &buf, testProblems(t))
+	// The report is dated.
+	date := regexp.MustCompile(`Generated \d{4}-\d{2}-\d{2} \d{2}:\d{2}`)
+	if !date.Match(out) {
+		t.Fatal("report isn't dated")
+	}
+	golden(t, "html", date.ReplaceAll(out, []byte("Generated $$DATE")))
+}
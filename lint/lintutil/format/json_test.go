@@ -0,0 +1,35 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	var buf bytes.Buffer
+	got := run(JSON{W: &buf, DocURL: testDocURL}, &buf, testProblems(t))
+	golden(t, "json", got)
+}
+
+func TestJSONProblem(t *testing.T) {
+	for _, p := range testProblems(t) {
+		b, err := json.Marshal(NewJSONProblem(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var jp JSONProblem
+		if err := json.Unmarshal(b, &jp); err != nil {
+			t.Fatal(err)
+		}
+		if p.Package != nil && jp.Package != p.Package.ID {
+			t.Errorf("%s: got package %q, want %q", p.Text, jp.Package, p.Package.ID)
+		}
+		got := jp.Problem()
+		p.Package = nil
+		if !reflect.DeepEqual(got, p) {
+			t.Errorf("got %+v, want %+v", got, p)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package format
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"honnef.co/go/tools/lint"
+)
+
+// JUnit formats problems as a JUnit XML test report. Each package is
+// a test suite, and each problem is a failing test case named after
+// its check. Ignored problems are skipped test cases. Problems are
+// buffered and written by Stats.
+type JUnit struct {
+	W io.Writer
+	// Tool is the name of the linter, which names the report.
+	Tool string
+	// Packages are the import paths of the linted packages. Packages
+	// without problems are reported as a suite with a single passing
+	// test case, so that a clean run doesn't produce an empty report.
+	Packages []string
+
+	suites []*junitSuite
+	index  map[string]int
+}
+
+type junitReport struct {
+	XMLName  xml.Name      `xml:"testsuites"`
+	Name     string        `xml:"name,attr,omitempty"`
+	Tests    int           `xml:"tests,attr"`
+	Failures int           `xml:"failures,attr"`
+	Skipped  int           `xml:"skipped,attr"`
+	Suites   []*junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Failure   *junitFailure `xml:"failure"`
+	Skipped   *junitSkipped `xml:"skipped"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+func (o *JUnit) Format(p lint.Problem) {
+	if o.index == nil {
+		o.index = map[string]int{}
+	}
+	// Problems that don't belong to a package, such as unmatched
+	// linter directives, are grouped in a suite of their own.
+	name := "-"
+	if p.Package != nil && p.Package.Package != nil {
+		name = p.Package.PkgPath
+	}
+	i, ok := o.index[name]
+	if !ok {
+		i = len(o.suites)
+		o.index[name] = i
+		o.suites = append(o.suites, &junitSuite{Name: name})
+	}
+	s := o.suites[i]
+
+	id := p.Check
+	if id == "" {
+		id = p.Checker
+	}
+	c := junitCase{
+		Name:      id,
+		Classname: name,
+		File:      p.Position.Filename,
+		Line:      p.Position.Line,
+	}
+	s.Tests++
+	if p.Severity == lint.Ignored {
+		c.Skipped = &junitSkipped{}
+		if p.Suppression != nil {
+			c.Skipped.Message = p.Suppression.Reason
+		}
+		s.Skipped++
+	} else {
+		c.Failure = &junitFailure{
+			Message: p.Text,
+			Type:    severity(p.Severity),
+			Text:    fmt.Sprintf("%s: %s", RelativePosition(p.Position), p.String()),
+		}
+		s.Failures++
+	}
+	s.Cases = append(s.Cases, c)
+}
+
+func (o *JUnit) Stats(total, errors, warnings int) {
+	name := o.Tool
+	if name == "" {
+		name = "lint"
+	}
+	for _, pkg := range o.Packages {
+		if _, ok := o.index[pkg]; ok {
+			continue
+		}
+		if o.index == nil {
+			o.index = map[string]int{}
+		}
+		o.index[pkg] = len(o.suites)
+		o.suites = append(o.suites, &junitSuite{
+			Name:  pkg,
+			Tests: 1,
+			Cases: []junitCase{{Name: name, Classname: pkg}},
+		})
+	}
+
+	r := junitReport{Name: o.Tool, Suites: o.suites}
+	for _, s := range o.suites {
+		r.Tests += s.Tests
+		r.Failures += s.Failures
+		r.Skipped += s.Skipped
+	}
+	writeXML(o.W, r)
+}
@@ -0,0 +1,17 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	f := &JUnit{W: &buf, Tool: "staticcheck", Packages: []string{"example.com/p", "example.com/clean"}}
+	golden(t, "junit", run(f, &buf, testProblems(t)))
+
+	// A clean run reports passing tests rather than an empty report.
+	buf.Reset()
+	f = &JUnit{W: &buf, Tool: "staticcheck", Packages: []string{"example.com/p", "example.com/clean"}}
+	golden(t, "junit_clean", run(f, &buf, nil))
+}
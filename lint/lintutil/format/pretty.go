@@ -0,0 +1,193 @@
+package format
+
+import (
+	"fmt"
+	"go/token"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"honnef.co/go/tools/lint"
+)
+
+// Pretty formats problems like modern compilers do, showing the
+// offending source code with the range of the problem underlined,
+// followed by related locations. Stats prints a summary of the
+// problems per check.
+type Pretty struct {
+	W io.Writer
+	// Color enables colored output, using ANSI escape sequences.
+	Color bool
+	// DocURL, if set, returns a link to the documentation of a
+	// check, which is printed after each problem.
+	DocURL func(check string) string
+
+	source sourceCache
+	// counts maps checks to the number of problems they reported.
+	counts map[string]int
+}
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[1;31m"
+	ansiYellow = "\x1b[1;33m"
+	ansiBlue   = "\x1b[1;34m"
+	ansiCyan   = "\x1b[1;36m"
+	ansiGray   = "\x1b[90m"
+)
+
+func (o *Pretty) color(color, s string) string {
+	if !o.Color {
+		return s
+	}
+	return color + s + ansiReset
+}
+
+func severityColor(s lint.Severity) string {
+	switch s {
+	case lint.Warning:
+		return ansiYellow
+	case lint.Info:
+		return ansiBlue
+	case lint.Hint:
+		return ansiCyan
+	case lint.Ignored:
+		return ansiGray
+	}
+	return ansiRed
+}
+
+func (o *Pretty) Format(p lint.Problem) {
+	if o.source == nil {
+		o.source = sourceCache{}
+		o.counts = map[string]int{}
+	}
+	id := p.Check
+	if id == "" {
+		id = p.Checker
+	}
+	o.counts[id]++
+
+	sev := severity(p.Severity)
+	if sev == "" {
+		sev = "error"
+	}
+	head := o.color(severityColor(p.Severity), sev)
+	if p.Check != "" {
+		head += o.color(severityColor(p.Severity), "["+p.Check+"]")
+	}
+	fmt.Fprintf(o.W, "%s%s\n", head, o.color(ansiBold, ": "+p.Text))
+	if p.Position.Filename == "" {
+		fmt.Fprintln(o.W)
+		return
+	}
+
+	width := len(strconv.Itoa(p.Position.Line))
+	for _, r := range p.Related {
+		if w := len(strconv.Itoa(r.Position.Line)); w > width {
+			width = w
+		}
+	}
+	gutter := strings.Repeat(" ", width)
+
+	fmt.Fprintf(o.W, "%s%s %s\n", gutter, o.color(ansiBlue, "-->"), RelativePosition(p.Position))
+	o.snippet(gutter, p.Position, p.End, "", severityColor(p.Severity))
+	for _, r := range p.Related {
+		fmt.Fprintf(o.W, "%s%s %s\n", gutter, o.color(ansiBlue, ":::"), RelativePosition(r.Position))
+		o.snippet(gutter, r.Position, r.Position, r.Message, ansiBlue)
+	}
+	if len(p.Edits) > 0 {
+		fmt.Fprintf(o.W, "%s %s %s\n", gutter, o.color(ansiBlue, "="), o.color(ansiBold, "help:")+" a suggested fix is available")
+	}
+	if p.Severity == lint.Ignored && p.Suppression != nil {
+		fmt.Fprintf(o.W, "%s %s %s %s\n", gutter, o.color(ansiBlue, "="), o.color(ansiBold, "ignored:"), p.Suppression.Reason)
+	}
+	if url := docURL(o.DocURL, p); url != "" {
+		fmt.Fprintf(o.W, "%s %s %s %s\n", gutter, o.color(ansiBlue, "="), o.color(ansiBold, "see:"), url)
+	}
+	fmt.Fprintln(o.W)
+}
+
+// snippet prints the source line of start, underlining the range up
+// to end, or a single character if end isn't on the same line.
+func (o *Pretty) snippet(gutter string, start, end token.Position, label, color string) {
+	line, ok := o.source.line(start.Filename, start.Line)
+	if !ok {
+		return
+	}
+	bar := o.color(ansiBlue, "|")
+	fmt.Fprintf(o.W, "%s %s\n", gutter, bar)
+	fmt.Fprintf(o.W, "%s %s %s\n", o.color(ansiBlue, fmt.Sprintf("%*d", len(gutter), start.Line)), bar, line)
+
+	col := start.Column - 1
+	if col < 0 || col > len(line) {
+		col = len(line)
+	}
+	// Keep tabs so that the underline lines up with the source.
+	var indent strings.Builder
+	for _, r := range line[:col] {
+		if r == '\t' {
+			indent.WriteByte('\t')
+		} else {
+			indent.WriteByte(' ')
+		}
+	}
+	n := 1
+	switch {
+	case end.Line == start.Line && end.Column > start.Column:
+		ecol := end.Column - 1
+		if ecol > len(line) {
+			ecol = len(line)
+		}
+		n = utf8.RuneCountInString(line[col:ecol])
+	case end.Line > start.Line:
+		n = utf8.RuneCountInString(line[col:])
+	}
+	if n < 1 {
+		n = 1
+	}
+	underline := strings.Repeat("^", n)
+	if label != "" {
+		underline += " " + label
+	}
+	fmt.Fprintf(o.W, "%s %s %s%s\n", gutter, bar, indent.String(), o.color(color, underline))
+}
+
+func (o *Pretty) Stats(total, errors, warnings int) {
+	if len(o.counts) > 0 {
+		checks := make([]string, 0, len(o.counts))
+		for check := range o.counts {
+			checks = append(checks, check)
+		}
+		sort.Slice(checks, func(i, j int) bool {
+			ci, cj := o.counts[checks[i]], o.counts[checks[j]]
+			if ci != cj {
+				return ci > cj
+			}
+			return checks[i] < checks[j]
+		})
+		tw := tabwriter.NewWriter(o.W, 0, 4, 2, ' ', 0)
+		// Escape sequences would throw off the alignment of the
+		// table, so it isn't colored.
+		fmt.Fprintln(tw, "check\tproblems")
+		for _, check := range checks {
+			fmt.Fprintf(tw, "%s\t%d\n", check, o.counts[check])
+		}
+		tw.Flush()
+		fmt.Fprintln(o.W)
+	}
+	summary := fmt.Sprintf("%d problems (%d errors, %d warnings)", total, errors, warnings)
+	switch {
+	case errors > 0:
+		summary = o.color(ansiRed, summary)
+	case warnings > 0:
+		summary = o.color(ansiYellow, summary)
+	default:
+		summary = o.color(ansiBold, summary)
+	}
+	fmt.Fprintln(o.W, summary)
+}
@@ -0,0 +1,14 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPretty(t *testing.T) {
+	var buf bytes.Buffer
+	golden(t, "pretty", run(&Pretty{W: &buf, DocURL: testDocURL}, &buf, testProblems(t)))
+
+	buf.Reset()
+	golden(t, "pretty_color", run(&Pretty{W: &buf, Color: true}, &buf, testProblems(t)[:2]))
+}
@@ -0,0 +1,340 @@
+package format
+
+import (
+	"encoding/json"
+	"go/token"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"honnef.co/go/tools/lint"
+)
+
+const sarifSchema = "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json"
+
+// srcRoot is the base of relative URIs, the current directory.
+const srcRoot = "%SRCROOT%"
+
+// SARIF formats problems as a SARIF 2.1.0 log with a single run.
+// Because the log is a single JSON document, problems are buffered
+// and written by Stats.
+type SARIF struct {
+	W io.Writer
+	// Tool and Version identify the linter in the run.
+	Tool    string
+	Version string
+	// Docs maps check IDs to their documentation. The rules of the
+	// run are the documented checks.
+	Docs map[string]*lint.Documentation
+	// DocURL, if set, returns a link to the documentation of a
+	// check, which is used as the rule's help URI.
+	DocURL func(check string) string
+
+	results []sarifResult
+	rules   []sarifRule
+	// ruleIndex maps rule IDs to their index in rules.
+	ruleIndex map[string]int
+	// source caches the lines of files, for converting columns.
+	source sourceCache
+	cwd    string
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                        `json:"tool"`
+	OriginalURIBaseIDs map[string]sarifArtifactLocation `json:"originalUriBaseIds,omitempty"`
+	Results            []sarifResult                    `json:"results"`
+	ColumnKind         string                           `json:"columnKind"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string                  `json:"id"`
+	ShortDescription     *sarifMessage           `json:"shortDescription,omitempty"`
+	FullDescription      *sarifMessage           `json:"fullDescription,omitempty"`
+	HelpURI              string                  `json:"helpUri,omitempty"`
+	DefaultConfiguration *sarifRuleConfiguration `json:"defaultConfiguration,omitempty"`
+	Properties           map[string]interface{}  `json:"properties,omitempty"`
+}
+
+type sarifRuleConfiguration struct {
+	Enabled bool `json:"enabled"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID           string             `json:"ruleId"`
+	RuleIndex        int                `json:"ruleIndex"`
+	Level            string             `json:"level,omitempty"`
+	Message          sarifMessage       `json:"message"`
+	Locations        []sarifLocation    `json:"locations,omitempty"`
+	RelatedLocations []sarifLocation    `json:"relatedLocations,omitempty"`
+	Suppressions     []sarifSuppression `json:"suppressions,omitempty"`
+	Fixes            []sarifFix         `json:"fixes,omitempty"`
+}
+
+type sarifLocation struct {
+	ID               int                   `json:"id,omitempty"`
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	Message          *sarifMessage         `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine,omitempty"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+type sarifSuppression struct {
+	// Kind is "inSource" for linter directives and "external" for
+	// other ignores, such as the -ignore flag.
+	Kind          string         `json:"kind"`
+	Justification string         `json:"justification,omitempty"`
+	Location      *sarifLocation `json:"location,omitempty"`
+}
+
+type sarifFix struct {
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []sarifReplacement    `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion  `json:"deletedRegion"`
+	InsertedContent sarifMessage `json:"insertedContent"`
+}
+
+func (o *SARIF) init() {
+	if o.ruleIndex != nil {
+		return
+	}
+	o.ruleIndex = map[string]int{}
+	o.source = sourceCache{}
+	o.cwd, _ = os.Getwd()
+
+	ids := make([]string, 0, len(o.Docs))
+	for id := range o.Docs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		doc := o.Docs[id]
+		r := sarifRule{
+			ID:                   id,
+			ShortDescription:     &sarifMessage{Text: doc.Title},
+			HelpURI:              docURL(o.DocURL, lint.Problem{Check: id}),
+			DefaultConfiguration: &sarifRuleConfiguration{Enabled: !doc.NonDefault},
+		}
+		if doc.Text != "" {
+			r.FullDescription = &sarifMessage{Text: doc.Text}
+		}
+		if doc.Category != "" {
+			r.Properties = map[string]interface{}{"category": doc.Category}
+		}
+		o.addRule(r)
+	}
+}
+
+func (o *SARIF) addRule(r sarifRule) int {
+	if i, ok := o.ruleIndex[r.ID]; ok {
+		return i
+	}
+	o.ruleIndex[r.ID] = len(o.rules)
+	o.rules = append(o.rules, r)
+	return len(o.rules) - 1
+}
+
+func sarifLevel(s lint.Severity) string {
+	switch s {
+	case lint.Error:
+		return "error"
+	case lint.Warning:
+		return "warning"
+	case lint.Info, lint.Hint:
+		return "note"
+	}
+	// Ignored problems don't retain their severity; SARIF's default
+	// level is "warning".
+	return ""
+}
+
+// artifact returns the location of the file filename, relative to the
+// current directory if possible.
+func (o *SARIF) artifact(filename string) sarifArtifactLocation {
+	if o.cwd != "" {
+		if rel, err := filepath.Rel(o.cwd, filename); err == nil && !strings.HasPrefix(rel, "..") {
+			return sarifArtifactLocation{URI: (&url.URL{Path: filepath.ToSlash(rel)}).String(), URIBaseID: srcRoot}
+		}
+	}
+	return sarifArtifactLocation{URI: fileURI(filename)}
+}
+
+func fileURI(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		// Windows paths have a drive letter.
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// column converts the byte column of pos to a column in Unicode code
+// points, as declared by the run's column kind.
+func (o *SARIF) column(pos token.Position) int {
+	line, ok := o.source.line(pos.Filename, pos.Line)
+	if !ok || pos.Column < 1 || pos.Column-1 > len(line) {
+		return pos.Column
+	}
+	return utf8.RuneCountInString(line[:pos.Column-1]) + 1
+}
+
+func (o *SARIF) region(start, end token.Position) *sarifRegion {
+	if !start.IsValid() {
+		return nil
+	}
+	r := &sarifRegion{StartLine: start.Line, StartColumn: o.column(start)}
+	if end.IsValid() {
+		r.EndLine = end.Line
+		r.EndColumn = o.column(end)
+	}
+	return r
+}
+
+func (o *SARIF) location(pos token.Position) sarifLocation {
+	return sarifLocation{
+		PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: o.artifact(pos.Filename),
+			Region:           o.region(pos, token.Position{}),
+		},
+	}
+}
+
+func (o *SARIF) Format(p lint.Problem) {
+	o.init()
+	// Problems that don't belong to a check, such as compilation
+	// errors, use the checker as their rule.
+	id := p.Check
+	if id == "" {
+		id = p.Checker
+	}
+	res := sarifResult{
+		RuleID:    id,
+		RuleIndex: o.addRule(sarifRule{ID: id}),
+		Level:     sarifLevel(p.Severity),
+		Message:   sarifMessage{Text: p.Text},
+	}
+	if p.Position.Filename != "" {
+		loc := o.location(p.Position)
+		if p.End.Filename == p.Position.Filename {
+			loc.PhysicalLocation.Region = o.region(p.Position, p.End)
+		}
+		res.Locations = []sarifLocation{loc}
+	}
+	for i, r := range p.Related {
+		loc := o.location(r.Position)
+		loc.ID = i + 1
+		loc.Message = &sarifMessage{Text: r.Message}
+		res.RelatedLocations = append(res.RelatedLocations, loc)
+	}
+	if p.Severity == lint.Ignored {
+		s := sarifSuppression{Kind: "external"}
+		if d := p.Suppression; d != nil {
+			loc := o.location(d.Position)
+			s = sarifSuppression{Kind: "inSource", Justification: d.Reason, Location: &loc}
+		}
+		res.Suppressions = []sarifSuppression{s}
+	}
+	if len(p.Edits) > 0 {
+		var fix sarifFix
+		changes := map[string]int{}
+		for _, e := range p.Edits {
+			r := o.region(e.Start, e.End)
+			if r == nil {
+				continue
+			}
+			i, ok := changes[e.Start.Filename]
+			if !ok {
+				i = len(fix.ArtifactChanges)
+				changes[e.Start.Filename] = i
+				fix.ArtifactChanges = append(fix.ArtifactChanges, sarifArtifactChange{ArtifactLocation: o.artifact(e.Start.Filename)})
+			}
+			fix.ArtifactChanges[i].Replacements = append(fix.ArtifactChanges[i].Replacements, sarifReplacement{
+				DeletedRegion:   *r,
+				InsertedContent: sarifMessage{Text: e.NewText},
+			})
+		}
+		res.Fixes = []sarifFix{fix}
+	}
+	o.results = append(o.results, res)
+}
+
+func (o *SARIF) Stats(total, errors, warnings int) {
+	o.init()
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           o.Tool,
+			Version:        o.Version,
+			InformationURI: "https://staticcheck.io",
+			Rules:          o.rules,
+		}},
+		Results:    o.results,
+		ColumnKind: "unicodeCodePoints",
+	}
+	// The schema requires arrays, even if they're empty.
+	if run.Tool.Driver.Rules == nil {
+		run.Tool.Driver.Rules = []sarifRule{}
+	}
+	if run.Results == nil {
+		run.Results = []sarifResult{}
+	}
+	if o.cwd != "" {
+		dir := o.cwd
+		if !strings.HasSuffix(dir, string(filepath.Separator)) {
+			dir += string(filepath.Separator)
+		}
+		run.OriginalURIBaseIDs = map[string]sarifArtifactLocation{srcRoot: {URI: fileURI(dir)}}
+	}
+	enc := json.NewEncoder(o.W)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
@@ -0,0 +1,33 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	f := &SARIF{W: &buf, Tool: "staticcheck", Version: "2019.2", Docs: testDocs, DocURL: testDocURL}
+	out := run(f, &buf, testProblems(t))
+	golden(t, "sarif", out)
+
+	// Every result refers to the rule of its check.
+	var log sarifLog
+	if err := json.Unmarshal(out, &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Schema != sarifSchema || log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("got a log with schema %q, version %q and %d runs", log.Schema, log.Version, len(log.Runs))
+	}
+	rules := log.Runs[0].Tool.Driver.Rules
+	for _, res := range log.Runs[0].Results {
+		if res.RuleIndex < 0 || res.RuleIndex >= len(rules) || rules[res.RuleIndex].ID != res.RuleID {
+			t.Errorf("result for %s has rule index %d", res.RuleID, res.RuleIndex)
+		}
+	}
+
+	// Empty runs still have a list of results.
+	buf.Reset()
+	golden(t, "sarif_empty", run(&SARIF{W: &buf, Tool: "staticcheck"}, &buf, nil))
+}
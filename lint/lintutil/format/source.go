@@ -0,0 +1,25 @@
+package format
+
+import (
+	"io/ioutil"
+	"strings"
+)
+
+// sourceCache caches the lines of source files, keyed by file name.
+type sourceCache map[string][]string
+
+// line returns the n'th line of the file filename, counting from 1,
+// without its line terminator.
+func (c sourceCache) line(filename string, n int) (string, bool) {
+	lines, ok := c[filename]
+	if !ok {
+		if b, err := ioutil.ReadFile(filename); err == nil {
+			lines = strings.Split(string(b), "\n")
+		}
+		c[filename] = lines
+	}
+	if n < 1 || n > len(lines) {
+		return "", false
+	}
+	return strings.TrimSuffix(lines[n-1], "\r"), true
+}
@@ -0,0 +1,90 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"text/template"
+
+	"honnef.co/go/tools/lint"
+)
+
+// templateFuncs are the helper functions available to templates.
+var templateFuncs = template.FuncMap{
+	// relpath shortens a path to be relative to the current
+	// directory, if that is shorter.
+	"relpath": shortPath,
+	// position formats a position like the text format does.
+	"position": RelativePosition,
+	// severity returns the name of a severity, such as "error".
+	"severity": severity,
+	// json encodes a value as JSON, for example to escape strings.
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// Template formats each problem with a user-defined text/template.
+// The template may define templates named "header" and "footer",
+// which are executed before and after all problems with the totals
+// passed to Stats. Because the header needs the totals, output is
+// buffered until Stats is called. A newline is added to the output
+// of each problem that doesn't end in one.
+type Template struct {
+	W io.Writer
+
+	tmpl *template.Template
+	buf  bytes.Buffer
+	err  error
+}
+
+// TemplateStats is the data of the header and footer templates.
+type TemplateStats struct {
+	Total    int
+	Errors   int
+	Warnings int
+}
+
+// NewTemplate parses text as the template of a Template writing to
+// w.
+func NewTemplate(w io.Writer, text string) (*Template, error) {
+	tmpl, err := template.New("problem").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{W: w, tmpl: tmpl}, nil
+}
+
+func (o *Template) Format(p lint.Problem) {
+	if o.err != nil {
+		return
+	}
+	var b strings.Builder
+	if err := o.tmpl.Execute(&b, p); err != nil {
+		o.err = err
+		return
+	}
+	s := b.String()
+	if !strings.HasSuffix(s, "\n") {
+		s += "\n"
+	}
+	o.buf.WriteString(s)
+}
+
+func (o *Template) Stats(total, errors, warnings int) {
+	stats := TemplateStats{total, errors, warnings}
+	if t := o.tmpl.Lookup("header"); t != nil && o.err == nil {
+		o.err = t.Execute(o.W, stats)
+	}
+	o.buf.WriteTo(o.W)
+	if t := o.tmpl.Lookup("footer"); t != nil && o.err == nil {
+		o.err = t.Execute(o.W, stats)
+	}
+}
+
+// Err returns the first error executing the templates, if any.
+func (o *Template) Err() error {
+	return o.err
+}
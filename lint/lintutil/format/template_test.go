@@ -0,0 +1,35 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTemplate(t *testing.T) {
+	const text = `{{define "header"}}{{.Total}} problems, {{.Errors}} errors, {{.Warnings}} warnings
+{{end}}{{define "footer"}}end
+{{end}}{{position .Position}}: {{severity .Severity}}: {{json .Text}}{{with .Check}} [{{.}}]{{end}}`
+	var buf bytes.Buffer
+	f, err := NewTemplate(&buf, text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "template", run(f, &buf, testProblems(t)))
+	if err := f.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	buf.Reset()
+	f, err = NewTemplate(&buf, "{{.Position.Filename}}: {{.Missing}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	run(f, &buf, testProblems(t))
+	if f.Err() == nil {
+		t.Error("executing a template with a missing field succeeded")
+	}
+
+	if _, err := NewTemplate(&buf, "{{.Text"); err == nil {
+		t.Error("parsing a malformed template succeeded")
+	}
+}
@@ -0,0 +1,12 @@
+package p
+
+import "fmt"
+
+//lint:ignore ST1003 matches the protocol
+func Greet_user(name string) {
+	msg := "héllo, " + name
+	msg = msg
+	fmt.Println(msg)
+}
+
+func unused() {}
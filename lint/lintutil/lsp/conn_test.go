@@ -0,0 +1,32 @@
+package lsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestReply(t *testing.T) {
+	var buf bytes.Buffer
+	c := NewConn(strings.NewReader("Content-Length: 5\r\n\r\n{oops"), &buf)
+	_, err := c.Read()
+	if rerr, ok := err.(*Error); !ok || rerr.Code != CodeParseError {
+		t.Fatalf("got error %v, want a parse error", err)
+	}
+	if err := c.Reply(nil, nil, err); err != nil {
+		t.Fatal(err)
+	}
+	id := json.RawMessage("1")
+	if err := c.Reply(&id, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Decoding would turn a null ID into a nil one, so we look at
+	// the encoded responses.
+	for _, want := range []string{`"id":null`, `"id":1`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("got responses %s, want one with %s", buf.String(), want)
+		}
+	}
+}
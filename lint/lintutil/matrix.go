@@ -0,0 +1,245 @@
+package lintutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"honnef.co/go/tools/lint"
+)
+
+// A BuildConfig is one build configuration of a matrix. Empty fields
+// inherit the values of the surrounding Options, and Tags are added
+// to Options.Tags.
+type BuildConfig struct {
+	Name   string
+	GOOS   string
+	GOARCH string
+	Tags   []string
+}
+
+func (cfg BuildConfig) String() string {
+	if cfg.Name != "" {
+		return cfg.Name
+	}
+	var parts []string
+	if cfg.GOOS != "" || cfg.GOARCH != "" {
+		parts = append(parts, cfg.GOOS+"/"+cfg.GOARCH)
+	}
+	if len(cfg.Tags) > 0 {
+		parts = append(parts, "tags="+strings.Join(cfg.Tags, ","))
+	}
+	if len(parts) == 0 {
+		return "default"
+	}
+	return strings.Join(parts, " ")
+}
+
+// ParseBuildConfig parses a build configuration of the form
+// "goos/goarch tags=tag1,tag2". Either part may be omitted, and
+// either half of goos/goarch may be empty.
+func ParseBuildConfig(s string) (BuildConfig, error) {
+	cfg := BuildConfig{Name: strings.TrimSpace(s)}
+	for _, field := range strings.Fields(s) {
+		switch {
+		case strings.HasPrefix(field, "tags="):
+			cfg.Tags = append(cfg.Tags, strings.Split(strings.TrimPrefix(field, "tags="), ",")...)
+		case strings.Contains(field, "/"):
+			if cfg.GOOS != "" || cfg.GOARCH != "" {
+				return BuildConfig{}, fmt.Errorf("build configuration %q has more than one platform", s)
+			}
+			p := strings.SplitN(field, "/", 2)
+			cfg.GOOS, cfg.GOARCH = p[0], p[1]
+		default:
+			return BuildConfig{}, fmt.Errorf("malformed build configuration %q", s)
+		}
+	}
+	if cfg.Name == "" {
+		return BuildConfig{}, errors.New("empty build configuration")
+	}
+	return cfg, nil
+}
+
+type matrixFlag []BuildConfig
+
+func (m *matrixFlag) String() string {
+	var parts []string
+	for _, cfg := range *m {
+		parts = append(parts, cfg.String())
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (m *matrixFlag) Set(s string) error {
+	for _, part := range strings.Split(s, ";") {
+		cfg, err := ParseBuildConfig(part)
+		if err != nil {
+			return err
+		}
+		*m = append(*m, cfg)
+	}
+	return nil
+}
+
+func (m *matrixFlag) Get() interface{} {
+	return []BuildConfig(*m)
+}
+
+// buildEnv returns the environment to load packages in, or nil if
+// the current environment should be used.
+func buildEnv(opt *Options) []string {
+	if opt.GOOS == "" && opt.GOARCH == "" {
+		return nil
+	}
+	env := os.Environ()
+	if opt.GOOS != "" {
+		env = append(env, "GOOS="+opt.GOOS)
+	}
+	if opt.GOARCH != "" {
+		env = append(env, "GOARCH="+opt.GOARCH)
+	}
+	return env
+}
+
+// lintMatrix lints packages once per configuration in opt.Matrix and
+// merges the results. A problem is only kept if it was reported by
+// every configuration that compiled its file. In particular, an
+// identifier is only reported as unused if it is unused in all
+// configurations that include it. Errors, on the other hand, are
+// always kept.
+func lintMatrix(ctx context.Context, cs []lint.Checker, paths []string, opt *Options, ignores []lint.Ignore, stats *lint.PerfStats) (*result, error) {
+	var results []*result
+	for _, cfg := range opt.Matrix {
+		copt := *opt
+		copt.Matrix = nil
+		copt.Tags = append(opt.Tags[:len(opt.Tags):len(opt.Tags)], cfg.Tags...)
+		if cfg.GOOS != "" {
+			copt.GOOS = cfg.GOOS
+		}
+		if cfg.GOARCH != "" {
+			copt.GOARCH = cfg.GOARCH
+		}
+
+		var res *result
+		var err error
+		if opt.Cache != nil {
+			res, err = lintCached(ctx, opt.Cache, cs, paths, &copt, ignores, stats)
+		} else {
+			res, err = lintUncached(ctx, cs, paths, &copt, ignores, stats)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("build configuration %s: %s", cfg, err)
+		}
+		results = append(results, res)
+	}
+	return mergeResults(opt.Matrix, results), nil
+}
+
+// isError reports whether p is an error rather than a finding of a
+// check: a compilation error, a failed check, or a problem that isn't
+// in any file.
+func isError(p lint.Problem) bool {
+	return p.Checker == "compiler" || p.Checker == lint.InternalError || p.Position.Filename == ""
+}
+
+type problemKey struct {
+	filename     string
+	line, column int
+	check        string
+	text         string
+}
+
+// mergeResults merges the results of linting the same packages in
+// the build configurations cfgs.
+func mergeResults(cfgs []BuildConfig, results []*result) *result {
+	// compiled maps files to the number of configurations that
+	// compiled them.
+	compiled := map[string]int{}
+	for _, res := range results {
+		files := map[string]bool{}
+		for _, pkg := range res.packages {
+			for _, f := range pkg.CompiledGoFiles {
+				files[f] = true
+			}
+			for _, f := range pkg.GoFiles {
+				files[f] = true
+			}
+		}
+		for f := range files {
+			compiled[f]++
+		}
+	}
+
+	out := &result{}
+	reported := map[problemKey]int{}
+	first := map[problemKey]lint.Problem{}
+	// reporters are the configurations that reported errors.
+	reporters := map[problemKey][]string{}
+	var order []problemKey
+	for i, res := range results {
+		// The same problem may be reported more than once per
+		// configuration, for example in test variants of a package.
+		seen := map[problemKey]bool{}
+		for _, p := range res.problems {
+			k := problemKey{p.Position.Filename, p.Position.Line, p.Position.Column, p.Check, p.Text}
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			if reported[k] == 0 {
+				first[k] = p
+				order = append(order, k)
+			}
+			reported[k]++
+			if isError(p) {
+				reporters[k] = append(reporters[k], cfgs[i].String())
+			}
+		}
+	}
+	for _, k := range order {
+		if cs, ok := reporters[k]; ok {
+			// Errors, such as type errors, failed checks and
+			// failures to load a package, may be specific to a
+			// configuration, and are never dropped.
+			p := first[k]
+			if len(cs) < len(results) {
+				p.Text = fmt.Sprintf("%s (in build configuration %s)", p.Text, strings.Join(cs, ", "))
+			}
+			out.problems = append(out.problems, p)
+			continue
+		}
+		n := compiled[k.filename]
+		if n == 0 {
+			n = len(results)
+		}
+		if reported[k] >= n {
+			out.problems = append(out.problems, first[k])
+		}
+	}
+
+	pkgs := map[string]bool{}
+	for _, res := range results {
+		for _, pkg := range res.packages {
+			if !pkgs[pkg.ID] {
+				pkgs[pkg.ID] = true
+				out.packages = append(out.packages, pkg)
+			}
+		}
+	}
+
+	dirs := map[string]int{}
+	for _, res := range results {
+		for _, d := range res.directives {
+			key := d.Position.String()
+			if i, ok := dirs[key]; ok {
+				out.directives[i].Matched = out.directives[i].Matched || d.Matched
+				continue
+			}
+			dirs[key] = len(out.directives)
+			out.directives = append(out.directives, d)
+		}
+	}
+	return out
+}
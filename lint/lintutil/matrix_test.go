@@ -0,0 +1,158 @@
+package lintutil
+
+import (
+	"go/token"
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+	"honnef.co/go/tools/lint"
+)
+
+func TestParseBuildConfig(t *testing.T) {
+	tests := []struct {
+		in   string
+		want BuildConfig
+	}{
+		{"linux/amd64", BuildConfig{Name: "linux/amd64", GOOS: "linux", GOARCH: "amd64"}},
+		{" windows/ ", BuildConfig{Name: "windows/", GOOS: "windows"}},
+		{"/arm64", BuildConfig{Name: "/arm64", GOARCH: "arm64"}},
+		{"tags=foo,bar", BuildConfig{Name: "tags=foo,bar", Tags: []string{"foo", "bar"}}},
+		{"darwin/arm64 tags=cgo", BuildConfig{Name: "darwin/arm64 tags=cgo", GOOS: "darwin", GOARCH: "arm64", Tags: []string{"cgo"}}},
+	}
+	for _, tt := range tests {
+		got, err := ParseBuildConfig(tt.in)
+		if err != nil {
+			t.Errorf("%q: %s", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{
+		"",
+		"   ",
+		"linux",
+		"linux/amd64 darwin/arm64",
+		"linux/amd64 foo",
+	} {
+		if _, err := ParseBuildConfig(in); err == nil {
+			t.Errorf("%q: got no error", in)
+		}
+	}
+
+	var m matrixFlag
+	if err := m.Set("linux/amd64; windows/amd64 tags=foo"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.String(), "linux/amd64; windows/amd64 tags=foo"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	for _, in := range []string{"linux/amd64;", "linux/amd64;;windows/amd64", "linux/amd64;linux"} {
+		var m matrixFlag
+		if err := m.Set(in); err == nil {
+			t.Errorf("-matrix %q: got no error", in)
+		}
+	}
+}
+
+func TestMergeResults(t *testing.T) {
+	pkg := func(id string, files ...string) *packages.Package {
+		return &packages.Package{ID: id, GoFiles: files, CompiledGoFiles: files}
+	}
+	problem := func(file string, line int, check string) lint.Problem {
+		p := lint.Problem{Check: check, Text: check + " problem"}
+		if file != "" {
+			p.Position = token.Position{Filename: file, Line: line, Column: 1}
+		}
+		return p
+	}
+	failure := func(file, checker, text string) lint.Problem {
+		p := problem(file, 1, "")
+		p.Checker, p.Text = checker, text
+		return p
+	}
+	linux := &result{
+		packages: []*packages.Package{pkg("p", "p.go", "p_linux.go")},
+	}
+	windows := &result{
+		packages: []*packages.Package{pkg("p", "p.go", "p_windows.go")},
+	}
+
+	tests := []struct {
+		name    string
+		linux   []lint.Problem
+		windows []lint.Problem
+		want    []lint.Problem
+	}{
+		{
+			name:    "reported by all configurations",
+			linux:   []lint.Problem{problem("p.go", 1, "A")},
+			windows: []lint.Problem{problem("p.go", 1, "A")},
+			want:    []lint.Problem{problem("p.go", 1, "A")},
+		},
+		{
+			name:  "reported by only one configuration",
+			linux: []lint.Problem{problem("p.go", 1, "A")},
+		},
+		{
+			name:    "file compiled in only one configuration",
+			linux:   []lint.Problem{problem("p_linux.go", 1, "A")},
+			windows: []lint.Problem{problem("p_windows.go", 2, "B")},
+			want:    []lint.Problem{problem("p_linux.go", 1, "A"), problem("p_windows.go", 2, "B")},
+		},
+		{
+			name:  "reported twice by one configuration",
+			linux: []lint.Problem{problem("p.go", 1, "A"), problem("p.go", 1, "A")},
+		},
+		{
+			name:    "reported twice by all configurations",
+			linux:   []lint.Problem{problem("p.go", 1, "A"), problem("p.go", 1, "A")},
+			windows: []lint.Problem{problem("p.go", 1, "A")},
+			want:    []lint.Problem{problem("p.go", 1, "A")},
+		},
+		{
+			name:  "no file, reported by one configuration",
+			linux: []lint.Problem{failure("", "", "couldn't load")},
+			want:  []lint.Problem{failure("", "", "couldn't load (in build configuration linux/amd64)")},
+		},
+		{
+			name:    "no file, reported by all configurations",
+			linux:   []lint.Problem{failure("", "", "couldn't load")},
+			windows: []lint.Problem{failure("", "", "couldn't load")},
+			want:    []lint.Problem{failure("", "", "couldn't load")},
+		},
+		{
+			name:  "compiler error in one configuration",
+			linux: []lint.Problem{failure("p.go", "compiler", "undeclared name: x")},
+			want:  []lint.Problem{failure("p.go", "compiler", "undeclared name: x (in build configuration linux/amd64)")},
+		},
+		{
+			name:    "internal error in one configuration",
+			windows: []lint.Problem{failure("p.go", lint.InternalError, "check A panicked")},
+			want:    []lint.Problem{failure("p.go", lint.InternalError, "check A panicked (in build configuration windows/amd64)")},
+		},
+	}
+	cfgs := []BuildConfig{{GOOS: "linux", GOARCH: "amd64"}, {GOOS: "windows", GOARCH: "amd64"}}
+	for _, tt := range tests {
+		linux.problems, windows.problems = tt.linux, tt.windows
+		got := mergeResults(cfgs, []*result{linux, windows})
+		if !reflect.DeepEqual(got.problems, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got.problems, tt.want)
+		}
+		if len(got.packages) != 1 {
+			t.Errorf("%s: got %d packages, want 1", tt.name, len(got.packages))
+		}
+	}
+
+	// A directive is matched if it matched in any configuration.
+	pos := token.Position{Filename: "p.go", Line: 3, Column: 1}
+	linux.directives = []lint.Directive{{Position: pos, Matched: false}}
+	windows.directives = []lint.Directive{{Position: pos, Matched: true}}
+	got := mergeResults(cfgs, []*result{linux, windows})
+	if len(got.directives) != 1 || !got.directives[0].Matched {
+		t.Errorf("got directives %+v, want one matched directive", got.directives)
+	}
+}
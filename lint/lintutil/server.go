@@ -0,0 +1,905 @@
+package lintutil
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/packages"
+	"honnef.co/go/tools/lint"
+	"honnef.co/go/tools/lint/lintutil/lsp"
+)
+
+var errExit = errors.New("exit")
+
+// serveLSP runs a language server on r and w until the client asks
+// it to exit.
+//
+// The server loads the packages of the workspace once and keeps them
+// in memory. When a document changes, only the packages containing it
+// and their reverse dependencies are parsed and type-checked again,
+// using the contents of open documents rather than those on disk.
+// The packages containing the document are linted after every
+// change; when it is saved, its reverse dependencies are linted, too.
+// Packages whose contents didn't change aren't type-checked again,
+// and their SSA form and the checkers' state are reused.
+func serveLSP(ctx context.Context, cs []lint.Checker, opt *Options, r io.Reader, w io.Writer) error {
+	ignores, err := parseIgnore(opt.Ignores)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s := &server{
+		conn:      lsp.NewConn(r, w),
+		cs:        cs,
+		opt:       opt,
+		ignores:   ignores,
+		linter:    newLinter(cs, opt, ignores),
+		overlay:   map[string][]byte{},
+		dirty:     map[string]bool{},
+		problems:  map[string][]lint.Problem{},
+		wake:      make(chan struct{}, 1),
+		stale:     map[string]bool{},
+		published: map[string]bool{},
+	}
+	go s.work(ctx)
+	return s.serve()
+}
+
+type server struct {
+	conn    *lsp.Conn
+	cs      []lint.Checker
+	opt     *Options
+	ignores []lint.Ignore
+
+	mu sync.Mutex
+	// root is the directory of the workspace.
+	root string
+	// overlay holds the contents of open documents.
+	overlay map[string][]byte
+	// dirty are the files that changed since they were last type
+	// checked, and whether they have been saved since.
+	dirty map[string]bool
+	// reload is set if the packages have to be loaded from scratch.
+	reload bool
+	// cancel cancels the current run of the worker.
+	cancel context.CancelFunc
+	// problems are the problems found in each file in the last run.
+	problems map[string][]lint.Problem
+	shutdown bool
+	wake     chan struct{}
+
+	// The following fields are only used by the worker.
+
+	fset *token.FileSet
+	// pkgs are all loaded packages, including dependencies, by ID.
+	pkgs map[string]*packages.Package
+	// roots are the IDs of the packages of the workspace.
+	roots map[string]bool
+	// files maps the files of the workspace to the IDs of the
+	// workspace packages containing them.
+	files map[string][]string
+	// stale are the IDs of workspace packages that need linting.
+	stale map[string]bool
+	// published are the files that we've last published a non-empty
+	// list of diagnostics for.
+	published map[string]bool
+	// sums are the hashes of the sources of workspace packages, as
+	// they were last type-checked, by ID.
+	sums map[string][sha256.Size]byte
+	// linter keeps the SSA form of the packages in ssaPkgs between
+	// runs, along with the state of the checkers initialized for it.
+	linter  *lint.Linter
+	ssaPkgs map[*packages.Package]bool
+}
+
+func (s *server) serve() error {
+	for {
+		msg, err := s.conn.Read()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			if rerr, ok := err.(*lsp.Error); ok {
+				s.conn.Reply(nil, nil, rerr)
+				continue
+			}
+			return err
+		}
+		result, err := s.handle(msg)
+		if err == errExit {
+			return nil
+		}
+		if msg.ID != nil {
+			if err := s.conn.Reply(msg.ID, result, err); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *server) handle(msg *lsp.Message) (interface{}, error) {
+	decode := func(v interface{}) error {
+		if err := json.Unmarshal(msg.Params, v); err != nil {
+			return &lsp.Error{Code: lsp.CodeInvalidParams, Message: err.Error()}
+		}
+		return nil
+	}
+
+	switch msg.Method {
+	case "initialize":
+		var params lsp.InitializeParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		root := params.RootPath
+		if params.RootURI != "" {
+			root = lsp.URIToPath(params.RootURI)
+		}
+		if root == "" {
+			var err error
+			root, err = os.Getwd()
+			if err != nil {
+				return nil, err
+			}
+		}
+		s.mu.Lock()
+		s.root = root
+		s.reload = true
+		s.mu.Unlock()
+		s.poke()
+		return lsp.InitializeResult{
+			Capabilities: lsp.ServerCapabilities{
+				TextDocumentSync: lsp.TextDocumentSyncOptions{
+					OpenClose: true,
+					Change:    lsp.SyncFull,
+					Save:      &lsp.SaveOptions{},
+				},
+				CodeActionProvider: true,
+			},
+		}, nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.mu.Lock()
+		s.shutdown = true
+		if s.cancel != nil {
+			s.cancel()
+		}
+		s.mu.Unlock()
+		return nil, nil
+	case "exit":
+		return nil, errExit
+	case "textDocument/didOpen":
+		var params lsp.DidOpenTextDocumentParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		s.change(lsp.URIToPath(params.TextDocument.URI), []byte(params.TextDocument.Text), false)
+		return nil, nil
+	case "textDocument/didChange":
+		var params lsp.DidChangeTextDocumentParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		path := lsp.URIToPath(params.TextDocument.URI)
+		s.mu.Lock()
+		src, ok := s.overlay[path]
+		s.mu.Unlock()
+		if !ok {
+			var err error
+			src, err = ioutil.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+		}
+		for _, ch := range params.ContentChanges {
+			if ch.Range == nil {
+				src = []byte(ch.Text)
+				continue
+			}
+			// We ask for full syncs, but don't rely on clients
+			// honoring that.
+			start, end := offset(src, ch.Range.Start), offset(src, ch.Range.End)
+			src = append(src[:start:start], append([]byte(ch.Text), src[end:]...)...)
+		}
+		s.change(path, src, false)
+		return nil, nil
+	case "textDocument/didSave":
+		var params lsp.DidSaveTextDocumentParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		path := lsp.URIToPath(params.TextDocument.URI)
+		s.mu.Lock()
+		src := s.overlay[path]
+		s.mu.Unlock()
+		if params.Text != nil {
+			src = []byte(*params.Text)
+		}
+		s.change(path, src, true)
+		return nil, nil
+	case "textDocument/didClose":
+		var params lsp.DidCloseTextDocumentParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		s.change(lsp.URIToPath(params.TextDocument.URI), nil, false)
+		return nil, nil
+	case "textDocument/codeAction":
+		var params lsp.CodeActionParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		return s.codeActions(params), nil
+	default:
+		if msg.ID == nil {
+			// Unknown notifications may be ignored
+			return nil, nil
+		}
+		return nil, &lsp.Error{Code: lsp.CodeMethodNotFound, Message: fmt.Sprintf("method %q not found", msg.Method)}
+	}
+}
+
+// change records new contents of a document and schedules it to be
+// checked again. The document is closed if src is nil.
+func (s *server) change(path string, src []byte, saved bool) {
+	s.mu.Lock()
+	prev, ok := s.overlay[path]
+	if !ok {
+		prev, _ = ioutil.ReadFile(path)
+	}
+	if src == nil {
+		delete(s.overlay, path)
+	} else {
+		s.overlay[path] = src
+	}
+	s.dirty[path] = s.dirty[path] || saved
+	cur := src
+	if cur == nil {
+		cur, _ = ioutil.ReadFile(path)
+	}
+	if !bytes.Equal(cur, prev) {
+		// The offsets of the last run's problems don't apply to
+		// the new contents, so we can't offer their fixes anymore.
+		s.dropProblems(path)
+	}
+	if saved && filepath.Ext(path) != ".go" {
+		// Changes to go.mod and the like may change the package
+		// graph.
+		s.reload = true
+	}
+	if s.cancel != nil {
+		// The results of the current run would be outdated anyway.
+		s.cancel()
+	}
+	s.mu.Unlock()
+	s.poke()
+}
+
+// dropProblems forgets the problems in path and those with suggested
+// edits in it. s.mu must be held.
+func (s *server) dropProblems(path string) {
+	delete(s.problems, path)
+	for file, ps := range s.problems {
+		var keep []lint.Problem
+		for _, p := range ps {
+			if !editsFile(p, path) {
+				keep = append(keep, p)
+			}
+		}
+		s.problems[file] = keep
+	}
+}
+
+// editsFile reports whether any of p's suggested edits are in path.
+func editsFile(p lint.Problem, path string) bool {
+	for _, e := range p.Edits {
+		if e.Start.Filename == path {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *server) poke() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *server) logf(format string, args ...interface{}) {
+	s.conn.Notify("window/logMessage", lsp.LogMessageParams{
+		Type:    lsp.MessageError,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// work updates and lints packages whenever documents change, until
+// ctx is cancelled.
+func (s *server) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		}
+
+		s.mu.Lock()
+		if s.shutdown || s.root == "" {
+			s.mu.Unlock()
+			continue
+		}
+		dirty, reload := s.dirty, s.reload
+		s.dirty, s.reload = map[string]bool{}, false
+		runCtx, cancel := context.WithCancel(ctx)
+		s.cancel = cancel
+		s.mu.Unlock()
+
+		err := s.update(runCtx, dirty, reload)
+		if err == nil {
+			err = s.lint(runCtx)
+		}
+		cancel()
+		if err != nil {
+			if runCtx.Err() == nil {
+				s.logf("%s", err)
+			}
+			if s.pkgs == nil {
+				// Try again on the next change
+				s.mu.Lock()
+				s.reload = true
+				s.mu.Unlock()
+			}
+		}
+	}
+}
+
+// source returns the contents of a file, preferring open documents.
+func (s *server) source(path string) ([]byte, error) {
+	s.mu.Lock()
+	src, ok := s.overlay[path]
+	s.mu.Unlock()
+	if ok {
+		return src, nil
+	}
+	return ioutil.ReadFile(path)
+}
+
+// update brings the packages up to date with the dirty files.
+func (s *server) update(ctx context.Context, dirty map[string]bool, reload bool) error {
+	if reload || s.pkgs == nil {
+		return s.load(ctx)
+	}
+
+	s.mu.Lock()
+	root := s.root
+	s.mu.Unlock()
+	changed := map[string]bool{}
+	saved := map[string]bool{}
+	for path, isSaved := range dirty {
+		ids, ok := s.files[path]
+		if !ok {
+			if filepath.Ext(path) == ".go" && strings.HasPrefix(path, root+string(filepath.Separator)) {
+				// A new file in the workspace
+				return s.load(ctx)
+			}
+			continue
+		}
+		for _, id := range ids {
+			changed[id] = true
+			if isSaved {
+				saved[id] = true
+			}
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	rdeps := map[string][]string{}
+	for id, pkg := range s.pkgs {
+		for _, imp := range pkg.Imports {
+			rdeps[imp.ID] = append(rdeps[imp.ID], id)
+		}
+	}
+	affected := map[string]bool{}
+	var mark func(id string, relint bool)
+	mark = func(id string, relint bool) {
+		if affected[id] {
+			return
+		}
+		affected[id] = true
+		if relint && s.roots[id] {
+			s.stale[id] = true
+		}
+		for _, rdep := range rdeps[id] {
+			mark(rdep, relint)
+		}
+	}
+	for id := range saved {
+		mark(id, true)
+	}
+	for id := range changed {
+		s.stale[id] = true
+		mark(id, false)
+	}
+
+	// Type-check affected packages after their dependencies.
+	done := map[string]bool{}
+	var visit func(pkg *packages.Package)
+	visit = func(pkg *packages.Package) {
+		if done[pkg.ID] || !affected[pkg.ID] {
+			return
+		}
+		done[pkg.ID] = true
+		depsChanged := false
+		for _, imp := range pkg.Imports {
+			visit(s.pkgs[imp.ID])
+			if s.pkgs[imp.ID] != imp {
+				depsChanged = true
+			}
+		}
+		sum, err := s.sum(pkg)
+		if err == nil && !depsChanged && sum == s.sums[pkg.ID] {
+			// Nothing changed since the package was last
+			// type-checked, for example when saving a document.
+			return
+		}
+		s.pkgs[pkg.ID] = s.recheck(pkg)
+		if err == nil {
+			s.sums[pkg.ID] = sum
+		} else {
+			delete(s.sums, pkg.ID)
+		}
+	}
+	ids := make([]string, 0, len(affected))
+	for id := range affected {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		visit(s.pkgs[id])
+	}
+	return nil
+}
+
+// load loads all packages of the workspace from scratch.
+func (s *server) load(ctx context.Context) error {
+	s.mu.Lock()
+	overlay := make(map[string][]byte, len(s.overlay))
+	for path, src := range s.overlay {
+		overlay[path] = src
+	}
+	root := s.root
+	s.mu.Unlock()
+
+	conf := &packages.Config{
+		Context: ctx,
+		Mode:    packages.LoadAllSyntax,
+		Tests:   s.opt.LintTests,
+		Dir:     root,
+		Fset:    token.NewFileSet(),
+		Overlay: overlay,
+		BuildFlags: []string{
+			"-tags=" + strings.Join(s.opt.Tags, " "),
+		},
+	}
+	initial, err := packages.Load(conf, "./...")
+	if err != nil {
+		return err
+	}
+
+	s.fset = conf.Fset
+	s.pkgs = map[string]*packages.Package{}
+	s.roots = map[string]bool{}
+	s.files = map[string][]string{}
+	s.stale = map[string]bool{}
+	s.sums = map[string][sha256.Size]byte{}
+	packages.Visit(initial, nil, func(pkg *packages.Package) {
+		s.pkgs[pkg.ID] = pkg
+	})
+	for _, pkg := range initial {
+		if isTestMain(pkg) || len(pkg.CompiledGoFiles) == 0 {
+			continue
+		}
+		s.roots[pkg.ID] = true
+		s.stale[pkg.ID] = true
+		if sum, err := s.sum(pkg); err == nil {
+			s.sums[pkg.ID] = sum
+		}
+		for _, f := range pkg.CompiledGoFiles {
+			s.files[f] = append(s.files[f], pkg.ID)
+		}
+	}
+	return nil
+}
+
+// sum hashes the current sources of pkg.
+func (s *server) sum(pkg *packages.Package) ([sha256.Size]byte, error) {
+	h := sha256.New()
+	for _, name := range pkg.CompiledGoFiles {
+		src, err := s.source(name)
+		if err != nil {
+			return [sha256.Size]byte{}, err
+		}
+		fmt.Fprintf(h, "%s %d\n", name, len(src))
+		h.Write(src)
+	}
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
+
+// recheck parses and type-checks pkg again, against the current
+// versions of its dependencies.
+func (s *server) recheck(old *packages.Package) *packages.Package {
+	pkg := &packages.Package{
+		ID:              old.ID,
+		Name:            old.Name,
+		PkgPath:         old.PkgPath,
+		GoFiles:         old.GoFiles,
+		CompiledGoFiles: old.CompiledGoFiles,
+		OtherFiles:      old.OtherFiles,
+		Imports:         map[string]*packages.Package{},
+		Fset:            s.fset,
+		TypesSizes:      old.TypesSizes,
+	}
+	for path, imp := range old.Imports {
+		pkg.Imports[path] = s.pkgs[imp.ID]
+	}
+
+	for _, name := range pkg.CompiledGoFiles {
+		src, err := s.source(name)
+		if err != nil {
+			pkg.Errors = append(pkg.Errors, packages.Error{Pos: name + ":1:1", Msg: err.Error()})
+			continue
+		}
+		f, err := parser.ParseFile(s.fset, name, src, parser.ParseComments|parser.AllErrors)
+		if err != nil {
+			if list, ok := err.(scanner.ErrorList); ok {
+				for _, err := range list {
+					pkg.Errors = append(pkg.Errors, packages.Error{Pos: err.Pos.String(), Msg: err.Msg})
+				}
+			} else {
+				pkg.Errors = append(pkg.Errors, packages.Error{Pos: name + ":1:1", Msg: err.Error()})
+			}
+		}
+		if f != nil {
+			pkg.Syntax = append(pkg.Syntax, f)
+		}
+	}
+
+	pkg.TypesInfo = &types.Info{
+		Types:      map[ast.Expr]types.TypeAndValue{},
+		Defs:       map[*ast.Ident]types.Object{},
+		Uses:       map[*ast.Ident]types.Object{},
+		Implicits:  map[ast.Node]types.Object{},
+		Selections: map[*ast.SelectorExpr]*types.Selection{},
+		Scopes:     map[ast.Node]*types.Scope{},
+	}
+	tc := &types.Config{
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			if path == "unsafe" {
+				return types.Unsafe, nil
+			}
+			imp, ok := pkg.Imports[path]
+			if !ok || imp.Types == nil {
+				return nil, fmt.Errorf("no package for import %s", path)
+			}
+			return imp.Types, nil
+		}),
+		Error: func(err error) {
+			if terr, ok := err.(types.Error); ok {
+				pkg.Errors = append(pkg.Errors, packages.Error{
+					Pos: s.fset.Position(terr.Pos).String(),
+					Msg: terr.Msg,
+				})
+			}
+		},
+		Sizes: pkg.TypesSizes,
+	}
+	pkg.Types, _ = tc.Check(pkg.PkgPath, s.fset, pkg.Syntax, pkg.TypesInfo)
+
+	pkg.IllTyped = len(pkg.Errors) > 0
+	for _, imp := range pkg.Imports {
+		if imp.IllTyped {
+			pkg.IllTyped = true
+		}
+	}
+	return pkg
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (fn importerFunc) Import(path string) (*types.Package, error) { return fn(path) }
+
+// lint lints the stale packages and publishes the problems found in
+// them.
+func (s *server) lint(ctx context.Context) error {
+	if len(s.stale) == 0 {
+		return nil
+	}
+	var (
+		problems []lint.Problem
+		initial  []*packages.Package
+		linted   []*packages.Package
+	)
+	for id := range s.stale {
+		pkg := s.pkgs[id]
+		linted = append(linted, pkg)
+		if pkg.IllTyped {
+			problems = append(problems, compileErrors(pkg)...)
+		} else {
+			initial = append(initial, pkg)
+		}
+	}
+	if len(initial) > 0 {
+		// Reuse the SSA form if it was built from the current
+		// versions of all packages involved; otherwise, it has to be
+		// built again, and the checkers initialized for it.
+		all := map[*packages.Package]bool{}
+		packages.Visit(initial, nil, func(pkg *packages.Package) {
+			all[pkg] = true
+		})
+		reuse := s.linter.SSA != nil
+		for pkg := range all {
+			if !s.ssaPkgs[pkg] {
+				reuse = false
+				break
+			}
+		}
+		if !reuse {
+			s.linter.SSA = nil
+			s.ssaPkgs = all
+		}
+		ps, err := s.linter.Lint(ctx, initial, nil)
+		if err != nil {
+			return err
+		}
+		problems = append(problems, ps...)
+	}
+	if s.opt.Baseline != nil {
+		var err error
+		problems, err = s.opt.Baseline.filter(problems, linted, false)
+		if err != nil {
+			return err
+		}
+	}
+	problems = lint.SortProblems(problems)
+
+	// Publish an empty list for files without problems, to clear
+	// their old diagnostics.
+	byFile := map[string][]lint.Problem{}
+	for _, pkg := range linted {
+		for _, f := range pkg.CompiledGoFiles {
+			byFile[f] = nil
+		}
+	}
+	for _, p := range problems {
+		if p.Severity == lint.Ignored || p.Position.Filename == "" {
+			continue
+		}
+		byFile[p.Position.Filename] = append(byFile[p.Position.Filename], p)
+	}
+	s.mu.Lock()
+	// Documents may have changed while we were linting, which
+	// cancels ctx. Checking it with the lock held makes sure that we
+	// don't store problems for outdated contents.
+	if err := ctx.Err(); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	for file, ps := range byFile {
+		s.problems[file] = ps
+	}
+	s.mu.Unlock()
+	s.stale = map[string]bool{}
+
+	files := make([]string, 0, len(byFile))
+	for file := range byFile {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		ps := byFile[file]
+		if len(ps) == 0 && !s.published[file] {
+			continue
+		}
+		s.published[file] = len(ps) > 0
+		diags := make([]lsp.Diagnostic, 0, len(ps))
+		for _, p := range ps {
+			diags = append(diags, s.diagnostic(p))
+		}
+		err := s.conn.Notify("textDocument/publishDiagnostics", lsp.PublishDiagnosticsParams{
+			URI:         lsp.PathToURI(file),
+			Diagnostics: diags,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *server) diagnostic(p lint.Problem) lsp.Diagnostic {
+	start := s.position(p.Position)
+	end := start
+	if p.End.IsValid() && p.End.Filename == p.Position.Filename {
+		end = s.position(p.End)
+	}
+	d := lsp.Diagnostic{
+		Range:   lsp.Range{Start: start, End: end},
+		Code:    p.Check,
+		Source:  p.Checker,
+		Message: p.Text,
+	}
+	switch p.Severity {
+	case lint.Error:
+		d.Severity = lsp.SeverityError
+	case lint.Warning:
+		d.Severity = lsp.SeverityWarning
+	case lint.Info:
+		d.Severity = lsp.SeverityInformation
+	case lint.Hint:
+		d.Severity = lsp.SeverityHint
+	}
+	for _, r := range p.Related {
+		pos := s.position(r.Position)
+		d.RelatedInformation = append(d.RelatedInformation, lsp.DiagnosticRelatedInformation{
+			Location: lsp.Location{
+				URI:   lsp.PathToURI(r.Position.Filename),
+				Range: lsp.Range{Start: pos, End: pos},
+			},
+			Message: r.Message,
+		})
+	}
+	return d
+}
+
+// position converts a position to the LSP's zero-based lines and
+// UTF-16 columns.
+func (s *server) position(pos token.Position) lsp.Position {
+	if pos.Line == 0 {
+		return lsp.Position{}
+	}
+	out := lsp.Position{Line: pos.Line - 1}
+	src, err := s.source(pos.Filename)
+	if err != nil {
+		out.Character = pos.Column - 1
+		return out
+	}
+	for i := 1; i < pos.Line; i++ {
+		j := bytes.IndexByte(src, '\n')
+		if j == -1 {
+			return out
+		}
+		src = src[j+1:]
+	}
+	if n := pos.Column - 1; n < len(src) {
+		src = src[:n]
+	}
+	out.Character = utf16Len(src)
+	return out
+}
+
+func utf16Len(b []byte) int {
+	n := 0
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		b = b[size:]
+		n += len(utf16.Encode([]rune{r}))
+	}
+	return n
+}
+
+// offsetPosition converts a byte offset in src to an LSP position.
+func offsetPosition(src []byte, off int) lsp.Position {
+	if off > len(src) {
+		off = len(src)
+	}
+	var pos lsp.Position
+	line := 0
+	for i, b := range src[:off] {
+		if b == '\n' {
+			pos.Line++
+			line = i + 1
+		}
+	}
+	pos.Character = utf16Len(src[line:off])
+	return pos
+}
+
+// offset converts an LSP position to a byte offset in src.
+func offset(src []byte, pos lsp.Position) int {
+	off := 0
+	for i := 0; i < pos.Line; i++ {
+		j := bytes.IndexByte(src[off:], '\n')
+		if j == -1 {
+			return len(src)
+		}
+		off += j + 1
+	}
+	for n := 0; n < pos.Character && off < len(src) && src[off] != '\n'; {
+		r, size := utf8.DecodeRune(src[off:])
+		n += len(utf16.Encode([]rune{r}))
+		off += size
+	}
+	return off
+}
+
+// codeActions returns quick fixes for the problems with suggested
+// fixes in the requested range.
+func (s *server) codeActions(params lsp.CodeActionParams) []lsp.CodeAction {
+	path := lsp.URIToPath(params.TextDocument.URI)
+	// The problems are only kept while the contents of the files
+	// they edit don't change, so we have to use the contents of the
+	// same moment.
+	s.mu.Lock()
+	ps := s.problems[path]
+	overlay := map[string][]byte{}
+	for _, p := range ps {
+		for _, e := range p.Edits {
+			if src, ok := s.overlay[e.Start.Filename]; ok {
+				overlay[e.Start.Filename] = src
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	actions := []lsp.CodeAction{}
+	for _, p := range ps {
+		if len(p.Edits) == 0 {
+			continue
+		}
+		d := s.diagnostic(p)
+		if d.Range.Start.Line < params.Range.Start.Line || d.Range.Start.Line > params.Range.End.Line {
+			continue
+		}
+		changes := map[string][]lsp.TextEdit{}
+		for _, e := range p.Edits {
+			src, ok := overlay[e.Start.Filename]
+			if !ok {
+				var err error
+				if src, err = ioutil.ReadFile(e.Start.Filename); err != nil {
+					continue
+				}
+			}
+			uri := lsp.PathToURI(e.Start.Filename)
+			changes[uri] = append(changes[uri], lsp.TextEdit{
+				Range: lsp.Range{
+					Start: offsetPosition(src, e.Start.Offset),
+					End:   offsetPosition(src, e.End.Offset),
+				},
+				NewText: e.NewText,
+			})
+		}
+		actions = append(actions, lsp.CodeAction{
+			Title:       "Fix: " + p.Text,
+			Kind:        "quickfix",
+			Diagnostics: []lsp.Diagnostic{d},
+			Edit:        &lsp.WorkspaceEdit{Changes: changes},
+		})
+	}
+	return actions
+}
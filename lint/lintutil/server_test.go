@@ -0,0 +1,252 @@
+package lintutil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"honnef.co/go/tools/lint"
+	"honnef.co/go/tools/lint/lintutil/lsp"
+)
+
+// nameChecker flags identifiers named "bad", suggesting to rename
+// them, and counts how often it is initialized.
+type nameChecker struct {
+	inits int
+}
+
+func (*nameChecker) Name() string              { return "names" }
+func (*nameChecker) Prefix() string            { return "TEST" }
+func (c *nameChecker) Init(prog *lint.Program) { c.inits++ }
+func (c *nameChecker) Checks() []lint.Check {
+	return []lint.Check{{ID: "TEST1000", Fn: c.check}}
+}
+
+func (c *nameChecker) check(j *lint.Job) {
+	for _, pkg := range j.Program.InitialPackages {
+		for _, f := range pkg.Syntax {
+			fset := j.Program.Fset()
+			ast.Inspect(f, func(node ast.Node) bool {
+				if ident, ok := node.(*ast.Ident); ok && ident.Name == "bad" {
+					edit := lint.Edit{Start: fset.Position(ident.Pos()), End: fset.Position(ident.End()), NewText: "good"}
+					p := j.ErrorfWithFix(ident, []lint.Edit{edit}, "bad name")
+					if p != nil {
+						p.Related = []lint.Related{{Position: fset.Position(f.Name.Pos()), Message: "in this package"}}
+					}
+				}
+				return true
+			})
+		}
+	}
+}
+
+// testClient is the client side of a language server connection.
+type testClient struct {
+	t    *testing.T
+	w    io.Writer
+	conn *lsp.Conn
+	id   int
+	// pending are notifications that were read while waiting for a
+	// response.
+	pending []*lsp.Message
+}
+
+// send sends a request, or a notification if request is false.
+func (c *testClient) send(method string, params interface{}, request bool) {
+	b, err := json.Marshal(params)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	msg := &lsp.Message{JSONRPC: "2.0", Method: method, Params: b}
+	if request {
+		c.id++
+		id := json.RawMessage(fmt.Sprint(c.id))
+		msg.ID = &id
+	}
+	b, err = json.Marshal(msg)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if _, err := fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n%s", len(b), b); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// diagnostics reads messages until diagnostics are published for
+// path.
+func (c *testClient) diagnostics(path string) []lsp.Diagnostic {
+	for {
+		var msg *lsp.Message
+		if len(c.pending) > 0 {
+			msg, c.pending = c.pending[0], c.pending[1:]
+		} else {
+			var err error
+			if msg, err = c.conn.Read(); err != nil {
+				c.t.Fatal(err)
+			}
+		}
+		if msg.Method == "window/logMessage" {
+			c.t.Fatalf("server logged an error: %s", msg.Params)
+		}
+		if msg.Method != "textDocument/publishDiagnostics" {
+			continue
+		}
+		var params lsp.PublishDiagnosticsParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			c.t.Fatal(err)
+		}
+		if lsp.URIToPath(params.URI) == path {
+			return params.Diagnostics
+		}
+	}
+}
+
+// call sends a request and decodes its result into result.
+func (c *testClient) call(method string, params, result interface{}) {
+	c.send(method, params, true)
+	for {
+		msg, err := c.conn.Read()
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		if msg.ID == nil || string(*msg.ID) != fmt.Sprint(c.id) {
+			c.pending = append(c.pending, msg)
+			continue
+		}
+		if msg.Error != nil {
+			c.t.Fatal(msg.Error)
+		}
+		b, err := json.Marshal(msg.Result)
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		if err := json.Unmarshal(b, result); err != nil {
+			c.t.Fatal(err)
+		}
+		return
+	}
+}
+
+func TestServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	write("go.mod", "module example.com/p\n")
+	path := write("p.go", "package p\n\nvar bad int\n")
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	c := &nameChecker{}
+	done := make(chan error, 1)
+	go func() {
+		done <- serveLSP(context.Background(), []lint.Checker{c}, &Options{}, inR, outW)
+		outW.Close()
+	}()
+	client := &testClient{t: t, w: inW, conn: lsp.NewConn(outR, ioutil.Discard)}
+
+	client.send("initialize", lsp.InitializeParams{RootURI: lsp.PathToURI(dir)}, true)
+	diags := client.diagnostics(path)
+	if len(diags) != 1 {
+		t.Fatalf("got %d diagnostics, want 1", len(diags))
+	}
+	want := lsp.Range{Start: lsp.Position{Line: 2, Character: 4}, End: lsp.Position{Line: 2, Character: 7}}
+	if diags[0].Range != want || diags[0].Code != "TEST1000" || diags[0].Message != "bad name" {
+		t.Errorf("got diagnostic %+v, want TEST1000 at %+v", diags[0], want)
+	}
+
+	change := func(text string) {
+		client.send("textDocument/didChange", lsp.DidChangeTextDocumentParams{
+			TextDocument:   lsp.TextDocumentIdentifier{URI: lsp.PathToURI(path)},
+			ContentChanges: []lsp.TextDocumentContentChangeEvent{{Text: text}},
+		}, false)
+	}
+
+	// Unchanged packages reuse the SSA form and initialized checkers.
+	change("package p\n\nvar bad int\n")
+	if diags := client.diagnostics(path); len(diags) != 1 {
+		t.Fatalf("got %d diagnostics, want 1", len(diags))
+	}
+	if c.inits != 1 {
+		t.Errorf("checker was initialized %d times, want 1", c.inits)
+	}
+
+	// Multibyte characters count as UTF-16 code units.
+	change("package p\n\nvar é, bad int\n")
+	diags = client.diagnostics(path)
+	want = lsp.Range{Start: lsp.Position{Line: 2, Character: 7}, End: lsp.Position{Line: 2, Character: 10}}
+	if len(diags) != 1 || diags[0].Range != want {
+		t.Fatalf("got diagnostics %+v, want one at %+v", diags, want)
+	}
+	if c.inits != 2 {
+		t.Errorf("checker was initialized %d times, want 2", c.inits)
+	}
+
+	// Quick fixes edit the current contents. Once the contents
+	// change, the fixes of the last run aren't offered anymore, until
+	// the new contents have been linted.
+	fixes := func(src string) int {
+		var actions []lsp.CodeAction
+		client.call("textDocument/codeAction", lsp.CodeActionParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: lsp.PathToURI(path)},
+			Range:        lsp.Range{End: lsp.Position{Line: 3}},
+		}, &actions)
+		for _, a := range actions {
+			for _, e := range a.Edit.Changes[lsp.PathToURI(path)] {
+				start, end := offset([]byte(src), e.Range.Start), offset([]byte(src), e.Range.End)
+				if src[start:end] != "bad" {
+					t.Errorf("quick fix replaces %q, want %q", src[start:end], "bad")
+				}
+			}
+		}
+		return len(actions)
+	}
+	if n := fixes("package p\n\nvar é, bad int\n"); n != 1 {
+		t.Errorf("got %d quick fixes, want 1", n)
+	}
+	src := "package p\n\nvar xyz, é, bad int\n"
+	change(src)
+	fixes(src)
+	if diags := client.diagnostics(path); len(diags) != 1 {
+		t.Fatalf("got %d diagnostics, want 1", len(diags))
+	}
+	if n := fixes(src); n != 1 {
+		t.Errorf("got %d quick fixes, want 1", n)
+	}
+
+	// Files that can't be read are reported at their start.
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	client.send("textDocument/didClose", lsp.DidCloseTextDocumentParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: lsp.PathToURI(path)},
+	}, false)
+	diags = client.diagnostics(path)
+	if len(diags) != 1 || diags[0].Code != "compile" || diags[0].Range.Start != (lsp.Position{}) {
+		t.Fatalf("got diagnostics %+v, want a compile error at the start of the file", diags)
+	}
+
+	go io.Copy(ioutil.Discard, outR)
+	client.send("shutdown", nil, true)
+	client.send("exit", nil, false)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
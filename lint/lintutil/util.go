@@ -8,13 +8,15 @@
 package lintutil // import "honnef.co/go/tools/lint/lintutil"
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
-	"go/build"
 	"go/token"
+	"io/ioutil"
 	"log"
 	"os"
+	"os/signal"
 	"regexp"
 	"runtime"
 	"runtime/pprof"
@@ -23,6 +25,7 @@ import (
 	"time"
 
 	"honnef.co/go/tools/config"
+	"honnef.co/go/tools/internal/cache"
 	"honnef.co/go/tools/lint"
 	"honnef.co/go/tools/lint/lintutil/format"
 	"honnef.co/go/tools/version"
@@ -59,13 +62,22 @@ func parseIgnore(s string) ([]lint.Ignore, error) {
 	return out, nil
 }
 
+// A versionFlag is a target Go version. The zero value means that
+// the version is derived from each package's module.
 type versionFlag int
 
 func (v *versionFlag) String() string {
+	if *v == 0 {
+		return "module"
+	}
 	return fmt.Sprintf("1.%d", *v)
 }
 
 func (v *versionFlag) Set(s string) error {
+	if s == "module" {
+		*v = 0
+		return nil
+	}
 	if len(s) < 3 {
 		return errors.New("invalid Go version")
 	}
@@ -101,17 +113,35 @@ func (list *list) Set(s string) error {
 }
 
 func FlagSet(name string) *flag.FlagSet {
-	flags := flag.NewFlagSet("", flag.ExitOnError)
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
 	flags.Usage = usage(name, flags)
 	flags.String("tags", "", "List of `build tags`")
 	flags.String("ignore", "", "Deprecated: use linter directives instead")
 	flags.Bool("tests", true, "Include tests")
 	flags.Bool("version", false, "Print version and exit")
 	flags.Bool("show-ignored", false, "Don't filter ignored problems")
-	flags.String("f", "text", "Output `format` (valid choices are 'stylish', 'text' and 'json')")
+	flags.String("f", "text", "Output `format` (valid choices are 'stylish', 'text', 'json', 'sarif', 'checkstyle', 'junit', 'pretty', 'html', 'template=<template>' and 'template-file=<file>')")
+	flags.Bool("cache", true, "Reuse results of unchanged packages from the cache")
+	flags.Bool("fix", false, "Apply suggested fixes and only report remaining problems")
+	flags.Bool("diff", false, "Print a diff of suggested fixes instead of applying them, failing if any problems can't be fixed")
+	flags.Bool("export-data", false, "Load dependencies from export data instead of source")
+	flags.String("baseline", "", "Don't report problems recorded in the baseline `file`")
+	flags.String("baseline-write", "", "Record all current problems in the baseline `file` and exit")
+	flags.String("diff-base", "", "Only report problems on lines changed since the git `revision`")
+	flags.String("diff-file", "", "Only report problems on lines added by the unified diff in `file`")
+	flags.Bool("list-ignores", false, "List all linter directives that suppress problems and exit")
+	flags.String("explain", "", "Print the documentation of `check` and exit")
+	flags.Bool("list-checks", false, "List all checks and exit")
+	flags.Bool("show-docs", false, "Include a link to the documentation of each problem's check")
+	flags.Bool("lsp", false, "Run as a language server, speaking the Language Server Protocol on stdin and stdout")
+	flags.Bool("daemon", false, "Run as a daemon, answering lint requests on the socket")
+	flags.Bool("connect", false, "Send the lint request to the daemon listening on the socket (incompatible with -matrix and -export-data)")
+	flags.String("socket", DefaultSocket(), "`path` of the daemon's Unix socket")
+	flags.Duration("job-timeout", 0, "Report checks that take longer than `duration` as failed instead of waiting for them")
 
 	flags.Int("debug.max-concurrent-jobs", 0, "Number of jobs to run concurrently")
 	flags.Bool("debug.print-stats", false, "Print debug statistics")
+	flags.Bool("debug.config", false, "Print the effective configuration of each package, and where its values come from, and exit")
 	flags.String("debug.cpuprofile", "", "Write CPU profile to `file`")
 	flags.String("debug.memprofile", "", "Write memory profile to `file`")
 
@@ -119,15 +149,9 @@ func FlagSet(name string) *flag.FlagSet {
 	fail := list{"all"}
 	flags.Var(&checks, "checks", "Comma-separated list of `checks` to enable.")
 	flags.Var(&fail, "fail", "Comma-separated list of `checks` that can cause a non-zero exit status.")
+	flags.Var(new(matrixFlag), "matrix", "Lint in each build `configuration` of the form 'goos/goarch tags=a,b' and merge the results (may be repeated, or separated by ';')")
 
-	tags := build.Default.ReleaseTags
-	v := tags[len(tags)-1][2:]
-	version := new(versionFlag)
-	if err := version.Set(v); err != nil {
-		panic(fmt.Sprintf("internal error: %s", err))
-	}
-
-	flags.Var(version, "go", "Target Go `version` in the format '1.x'")
+	flags.Var(new(versionFlag), "go", "Target Go `version` in the format '1.x', or 'module' to use each module's go.mod (the default; previously the version of the Go toolchain)")
 	return flags
 }
 
@@ -139,9 +163,28 @@ func ProcessFlagSet(cs []lint.Checker, fs *flag.FlagSet) {
 	formatter := fs.Lookup("f").Value.(flag.Getter).Get().(string)
 	printVersion := fs.Lookup("version").Value.(flag.Getter).Get().(bool)
 	showIgnored := fs.Lookup("show-ignored").Value.(flag.Getter).Get().(bool)
+	useCache := fs.Lookup("cache").Value.(flag.Getter).Get().(bool)
+	fix := fs.Lookup("fix").Value.(flag.Getter).Get().(bool)
+	printDiff := fs.Lookup("diff").Value.(flag.Getter).Get().(bool)
+	exportData := fs.Lookup("export-data").Value.(flag.Getter).Get().(bool)
+	baselineFile := fs.Lookup("baseline").Value.(flag.Getter).Get().(string)
+	baselineWrite := fs.Lookup("baseline-write").Value.(flag.Getter).Get().(string)
+	diffBase := fs.Lookup("diff-base").Value.(flag.Getter).Get().(string)
+	diffFile := fs.Lookup("diff-file").Value.(flag.Getter).Get().(string)
+	listIgnores := fs.Lookup("list-ignores").Value.(flag.Getter).Get().(bool)
+	explainCheck := fs.Lookup("explain").Value.(flag.Getter).Get().(string)
+	printChecks := fs.Lookup("list-checks").Value.(flag.Getter).Get().(bool)
+	showDocs := fs.Lookup("show-docs").Value.(flag.Getter).Get().(bool)
+	jobTimeout := fs.Lookup("job-timeout").Value.(flag.Getter).Get().(time.Duration)
+	serve := fs.Lookup("lsp").Value.(flag.Getter).Get().(bool)
+	daemon := fs.Lookup("daemon").Value.(flag.Getter).Get().(bool)
+	connect := fs.Lookup("connect").Value.(flag.Getter).Get().(bool)
+	socket := fs.Lookup("socket").Value.(flag.Getter).Get().(string)
+	matrix := fs.Lookup("matrix").Value.(flag.Getter).Get().([]BuildConfig)
 
 	maxConcurrentJobs := fs.Lookup("debug.max-concurrent-jobs").Value.(flag.Getter).Get().(int)
 	printStats := fs.Lookup("debug.print-stats").Value.(flag.Getter).Get().(bool)
+	printConfig := fs.Lookup("debug.config").Value.(flag.Getter).Get().(bool)
 	cpuProfile := fs.Lookup("debug.cpuprofile").Value.(flag.Getter).Get().(string)
 	memProfile := fs.Lookup("debug.memprofile").Value.(flag.Getter).Get().(string)
 
@@ -175,33 +218,260 @@ func ProcessFlagSet(cs []lint.Checker, fs *flag.FlagSet) {
 		exit(0)
 	}
 
-	ps, err := Lint(cs, fs.Args(), &Options{
+	if explainCheck != "" {
+		if err := explain(os.Stdout, cs, explainCheck); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+		exit(0)
+	}
+	if printChecks {
+		if err := listChecks(os.Stdout, cs, formatter); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+		exit(0)
+	}
+
+	var c *cache.Cache
+	if useCache {
+		var err error
+		c, err = cache.Default()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "couldn't open cache:", err)
+		}
+	}
+
+	if diffBase != "" && diffFile != "" {
+		fmt.Fprintln(os.Stderr, "-diff-base and -diff-file are mutually exclusive")
+		exit(2)
+	}
+	var changes *changes
+	if diffBase != "" || diffFile != "" {
+		var err error
+		if diffBase != "" {
+			changes, err = gitChanges(diffBase)
+		} else {
+			changes, err = diffFileChanges(diffFile)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "couldn't determine changed lines:", err)
+			exit(1)
+		}
+	}
+
+	var baseline *Baseline
+	if baselineFile != "" && baselineWrite == "" {
+		var err error
+		baseline, err = LoadBaseline(baselineFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "couldn't load baseline:", err)
+			exit(1)
+		}
+	}
+
+	// Stop gracefully on the first interrupt, so that profiles get
+	// written. A second interrupt terminates the process.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	go func() {
+		<-interrupts
+		signal.Stop(interrupts)
+		cancel()
+	}()
+
+	opt := &Options{
+		Cache:         c,
+		Baseline:      baseline,
 		Tags:          strings.Fields(tags),
 		LintTests:     tests,
 		Ignores:       ignore,
 		GoVersion:     goVersion,
 		ReturnIgnored: showIgnored,
+		ExportData:    exportData,
+		JobTimeout:    jobTimeout,
+		Matrix:        matrix,
 		Config:        cfg,
 
 		MaxConcurrentJobs: maxConcurrentJobs,
 		PrintStats:        printStats,
-	})
+	}
+
+	if printConfig {
+		traces, problems, err := traceConfigs(ctx, fs.Args(), opt)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+		for _, p := range problems {
+			fmt.Fprintf(os.Stderr, "warning: ignoring invalid configuration: %s\n", p)
+		}
+		if err := printConfigs(os.Stdout, traces, formatter); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+		exit(0)
+	}
+
+	if serve {
+		if err := serveLSP(ctx, cs, opt, os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+		exit(0)
+	}
+
+	if daemon {
+		l, err := listen(socket)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+		if err := NewDaemon(cs).Serve(ctx, l); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+		os.Remove(socket)
+		exit(0)
+	}
+
+	var (
+		res *result
+		err error
+	)
+	if connect {
+		if len(matrix) > 0 || exportData {
+			// The daemon loads packages from source, once per
+			// request.
+			fmt.Fprintln(os.Stderr, "-connect can't be combined with -matrix or -export-data")
+			exit(2)
+		}
+		var dir string
+		dir, err = os.Getwd()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+		res, err = lintRemote(ctx, socket, &LintArgs{
+			Dir:           dir,
+			Paths:         fs.Args(),
+			Tags:          opt.Tags,
+			LintTests:     opt.LintTests,
+			GoVersion:     opt.GoVersion,
+			Config:        opt.Config,
+			Ignores:       opt.Ignores,
+			ReturnIgnored: opt.ReturnIgnored,
+			Baseline:      baselineFile,
+			JobTimeout:    opt.JobTimeout,
+			Cache:         useCache,
+		})
+	} else {
+		res, err = lintPackages(ctx, cs, fs.Args(), opt)
+	}
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		exit(1)
 	}
 
+	if listIgnores {
+		if err := printDirectives(os.Stdout, res.directives, formatter); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+		exit(0)
+	}
+	ps := res.problems
+
+	if baselineWrite != "" {
+		n, err := WriteBaseline(baselineWrite, ps)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "couldn't write baseline:", err)
+			exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "wrote %d problems to %s\n", n, baselineWrite)
+		exit(0)
+	}
+
+	if changes != nil {
+		ps = changes.filter(ps)
+	}
+
+	if printDiff {
+		ps, err = applyFixes(ps, os.Stdout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+		// Like gofmt -d, only succeed if the diff fixes everything.
+		n := 0
+		for _, p := range ps {
+			if p.Severity != lint.Ignored {
+				n++
+			}
+		}
+		if n > 0 {
+			fmt.Fprintf(os.Stderr, "%d problems can't be fixed automatically\n", n)
+			exit(1)
+		}
+		exit(0)
+	}
+	if fix {
+		ps, err = applyFixes(ps, nil)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(1)
+		}
+	}
+
+	var doc func(check string) string
+	if showDocs {
+		doc = docURL(checkDocs(cs))
+	}
 	var f format.Formatter
 	switch formatter {
 	case "text":
-		f = format.Text{W: os.Stdout}
+		f = format.Text{W: os.Stdout, DocURL: doc}
 	case "stylish":
-		f = &format.Stylish{W: os.Stdout}
+		f = &format.Stylish{W: os.Stdout, DocURL: doc}
 	case "json":
-		f = format.JSON{W: os.Stdout}
+		f = format.JSON{W: os.Stdout, DocURL: doc}
+	case "sarif":
+		docs := checkDocs(cs)
+		f = &format.SARIF{W: os.Stdout, Tool: fs.Name(), Version: version.Version, Docs: docs, DocURL: docURL(docs)}
+	case "pretty":
+		f = &format.Pretty{W: os.Stdout, Color: isTerminal(os.Stdout), DocURL: doc}
+	case "html":
+		f = &format.HTML{W: os.Stdout, Tool: fs.Name(), DocURL: docURL(checkDocs(cs))}
+	case "checkstyle":
+		f = &format.Checkstyle{W: os.Stdout, Tool: fs.Name()}
+	case "junit":
+		var pkgs []string
+		seen := map[string]bool{}
+		for _, pkg := range res.packages {
+			if !seen[pkg.PkgPath] {
+				seen[pkg.PkgPath] = true
+				pkgs = append(pkgs, pkg.PkgPath)
+			}
+		}
+		f = &format.JUnit{W: os.Stdout, Tool: fs.Name(), Packages: pkgs}
 	default:
-		fmt.Fprintf(os.Stderr, "unsupported output format %q\n", formatter)
-		exit(2)
+		text, ok, err := templateFormat(formatter)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unsupported output format %q\n", formatter)
+			exit(2)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "couldn't read template:", err)
+			exit(2)
+		}
+		tf, err := format.NewTemplate(os.Stdout, text)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exit(2)
+		}
+		f = tf
 	}
 
 	var (
@@ -220,10 +490,15 @@ func ProcessFlagSet(cs []lint.Checker, fs *flag.FlagSet) {
 
 	total = len(ps)
 	for _, p := range ps {
-		if shouldExit[p.Check] {
-			errors++
-		} else {
-			p.Severity = lint.Warning
+		switch p.Severity {
+		case lint.Error:
+			if shouldExit[p.Check] {
+				errors++
+			} else {
+				p.Severity = lint.Warning
+				warnings++
+			}
+		case lint.Warning:
 			warnings++
 		}
 		f.Format(p)
@@ -231,6 +506,10 @@ func ProcessFlagSet(cs []lint.Checker, fs *flag.FlagSet) {
 	if f, ok := f.(format.Statter); ok {
 		f.Stats(total, errors, warnings)
 	}
+	if f, ok := f.(*format.Template); ok && f.Err() != nil {
+		fmt.Fprintln(os.Stderr, f.Err())
+		exit(2)
+	}
 	if errors > 0 {
 		exit(1)
 	}
@@ -238,18 +517,61 @@ func ProcessFlagSet(cs []lint.Checker, fs *flag.FlagSet) {
 
 type Options struct {
 	Config config.Config
-
-	Tags          []string
+	// Cache, if set, is used to reuse the results of packages that
+	// haven't changed since they were last linted.
+	Cache *cache.Cache
+	// Baseline, if set, suppresses known problems.
+	Baseline *Baseline
+
+	Tags []string
+	// GOOS and GOARCH, if set, override the platform to lint for.
+	GOOS          string
+	GOARCH        string
 	LintTests     bool
 	Ignores       string
 	GoVersion     int
 	ReturnIgnored bool
+	// ExportData causes dependencies to be loaded from export data
+	// instead of source. Checks learn what they need to know about
+	// dependencies from facts, which are computed once per
+	// dependency and stored in Cache.
+	ExportData bool
+	// JobTimeout, if positive, limits the time a single check may
+	// take. See lint.Linter.JobTimeout.
+	JobTimeout time.Duration
+	// Matrix, if set, lints packages once per build configuration
+	// and merges the results. See lintMatrix.
+	Matrix []BuildConfig
 
 	MaxConcurrentJobs int
 	PrintStats        bool
 }
 
+// result is the outcome of linting a set of packages.
+type result struct {
+	problems []lint.Problem
+	// packages are the packages that were linted.
+	packages []*packages.Package
+	// directives are the linter directives that suppress problems
+	// in the linted packages.
+	directives []lint.Directive
+}
+
 func Lint(cs []lint.Checker, paths []string, opt *Options) ([]lint.Problem, error) {
+	return LintContext(context.Background(), cs, paths, opt)
+}
+
+// LintContext is like Lint, but stops early and returns the context's
+// error if ctx is cancelled.
+func LintContext(ctx context.Context, cs []lint.Checker, paths []string, opt *Options) ([]lint.Problem, error) {
+	res, err := lintPackages(ctx, cs, paths, opt)
+	if err != nil {
+		return nil, err
+	}
+	return res.problems, nil
+}
+
+func lintPackages(ctx context.Context, cs []lint.Checker, paths []string, opt *Options) (*result, error) {
 	stats := lint.PerfStats{
 		CheckerInits: map[string]time.Duration{},
 	}
@@ -262,18 +584,42 @@ func Lint(cs []lint.Checker, paths []string, opt *Options) ([]lint.Problem, erro
 		return nil, err
 	}
 
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	var res *result
+	if len(opt.Matrix) > 0 {
+		res, err = lintMatrix(ctx, cs, paths, opt, ignores, &stats)
+	} else if opt.Cache != nil {
+		res, err = lintCached(ctx, opt.Cache, cs, paths, opt, ignores, &stats)
+	} else {
+		res, err = lintUncached(ctx, cs, paths, opt, ignores, &stats)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if opt.Baseline != nil {
+		res.problems, err = opt.Baseline.filter(res.problems, res.packages, opt.ReturnIgnored)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// lintUncached lints packages from scratch.
+func lintUncached(ctx context.Context, cs []lint.Checker, paths []string, opt *Options, ignores []lint.Ignore, stats *lint.PerfStats) (*result, error) {
 	conf := &packages.Config{
-		Mode:  packages.LoadAllSyntax,
-		Tests: opt.LintTests,
+		Context: ctx,
+		Mode:    loadMode(opt),
+		Tests:   opt.LintTests,
+		Env:     buildEnv(opt),
 		BuildFlags: []string{
 			"-tags=" + strings.Join(opt.Tags, " "),
 		},
 	}
 
 	t := time.Now()
-	if len(paths) == 0 {
-		paths = []string{"."}
-	}
 	pkgs, err := packages.Load(conf, paths...)
 	if err != nil {
 		return nil, err
@@ -291,22 +637,42 @@ func Lint(cs []lint.Checker, paths []string, opt *Options) ([]lint.Problem, erro
 	}
 
 	if len(workingPkgs) == 0 {
-		return problems, nil
+		return &result{problems: problems, packages: pkgs}, nil
+	}
+
+	l := newLinter(cs, opt, ignores)
+	if opt.ExportData {
+		store, err := dependencyFacts(ctx, opt.Cache, newKeyer(cs, opt), conf, workingPkgs)
+		if err != nil {
+			return nil, err
+		}
+		l.ImportObjectFact = store.Import
+	}
+	ps, err := l.Lint(ctx, workingPkgs, stats)
+	if err != nil {
+		return nil, err
 	}
+	problems = append(problems, ps...)
 
-	l := &lint.Linter{
+	return &result{
+		problems:   problems,
+		packages:   pkgs,
+		directives: l.Directives(),
+	}, nil
+}
+
+func newLinter(cs []lint.Checker, opt *Options, ignores []lint.Ignore) *lint.Linter {
+	return &lint.Linter{
 		Checkers:      cs,
 		Ignores:       ignores,
 		GoVersion:     opt.GoVersion,
 		ReturnIgnored: opt.ReturnIgnored,
 		Config:        opt.Config,
+		JobTimeout:    opt.JobTimeout,
 
 		MaxConcurrentJobs: opt.MaxConcurrentJobs,
 		PrintStats:        opt.PrintStats,
 	}
-	problems = append(problems, l.Lint(workingPkgs, &stats)...)
-
-	return problems, nil
 }
 
 var posRe = regexp.MustCompile(`^(.+?):(\d+):(\d+)?$`)
@@ -354,6 +720,29 @@ func compileErrors(pkg *packages.Package) []lint.Problem {
 	return ps
 }
 
+// isTerminal reports whether f is a terminal that we may color
+// output on. Setting NO_COLOR disables colors.
+func isTerminal(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// templateFormat returns the template of a "template=<template>" or
+// "template-file=<file>" output format.
+func templateFormat(formatter string) (text string, ok bool, err error) {
+	switch {
+	case strings.HasPrefix(formatter, "template="):
+		return strings.TrimPrefix(formatter, "template="), true, nil
+	case strings.HasPrefix(formatter, "template-file="):
+		b, err := ioutil.ReadFile(strings.TrimPrefix(formatter, "template-file="))
+		return string(b), true, err
+	}
+	return "", false, nil
+}
+
 func ProcessArgs(name string, cs []lint.Checker, args []string) {
 	flags := FlagSet(name)
 	flags.Parse(args)
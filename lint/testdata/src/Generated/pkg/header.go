@@ -0,0 +1,5 @@
+// Autogenerated by a tool that doesn't follow the convention.
+
+package pkg
+
+func header() {}
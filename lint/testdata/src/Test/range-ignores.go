@@ -0,0 +1,35 @@
+package pkg
+
+//lint:ignore-start TEST1000 legacy code
+func fn6() {}
+
+func fn7() {}
+
+//lint:ignore-end
+
+func fn8() {} // MATCH "test problem"
+
+//lint:ignore-start TEST1000 until=2000-01-01 expired long ago
+func fn9() {} // MATCH "test problem"
+
+//lint:ignore-end
+
+//lint:ignore-start TEST1000 until=2999-01-01 far in the future
+func fn10() {}
+
+//lint:ignore-end
+
+//lint:ignore-start TEST1000 nothing to ignore here
+var _ int
+
+//lint:ignore-end
+
+//lint:ignore-end
+
+//lint:ignore TEST1000 until=tomorrow reason
+func fn11() {} // MATCH "test problem"
+
+// MATCH:12 "expired on 2000-01-01: expired long ago"
+// MATCH:22 "didn't match anything"
+// MATCH:27 "without matching ignore-start"
+// MATCH:29 "invalid expiry date"
@@ -0,0 +1,8 @@
+package pkg
+
+func fn12() {} // MATCH "test problem"
+
+//lint:ignore-start TEST1000 forgot the end
+func fn13() {}
+
+// MATCH:5 "without matching ignore-end"
@@ -0,0 +1,7 @@
+package pkg
+
+//lint:file-ignore TEST1000 there are no functions in this file
+
+var _ int
+
+// MATCH:3 "didn't match anything"
@@ -8,6 +8,7 @@
 package testutil // import "honnef.co/go/tools/lint/testutil"
 
 import (
+	"context"
 	"fmt"
 	"go/parser"
 	"go/token"
@@ -26,9 +27,18 @@ import (
 )
 
 func TestAll(t *testing.T, c lint.Checker, dir string) {
+	testDocs(t, c)
 	testPackages(t, c, dir)
 }
 
+func testDocs(t *testing.T, c lint.Checker) {
+	for _, check := range c.Checks() {
+		if check.Doc == nil || check.Doc.Title == "" || check.Doc.Since == "" {
+			t.Errorf("check %s is missing documentation", check.ID)
+		}
+	}
+}
+
 func testPackages(t *testing.T, c lint.Checker, dir string) {
 	gopath := filepath.Join("testdata", dir)
 	gopath, err := filepath.Abs(gopath)
@@ -127,7 +137,10 @@ func lintGoVersion(
 	sources map[string][]byte,
 ) {
 	l := &lint.Linter{Checkers: []lint.Checker{c}, GoVersion: version, Config: config.Config{Checks: []string{"all"}}}
-	problems := l.Lint(pkgs, nil)
+	problems, err := l.Lint(context.Background(), pkgs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	for _, fi := range files {
 		src := sources[fi]
@@ -141,6 +154,9 @@ func lintGoVersion(
 					continue
 				}
 				if in.Match.MatchString(p.Text) {
+					if in.Replacement != "" {
+						checkFix(t, fi, src, in, p)
+					}
 					// remove this problem from ps
 					copy(problems[i:], problems[i+1:])
 					problems = problems[:len(problems)-1]
@@ -159,6 +175,38 @@ func lintGoVersion(
 	}
 }
 
+// checkFix applies the suggested fix of p to src and compares the
+// resulting line with the expected replacement.
+func checkFix(t *testing.T, filename string, src []byte, in instruction, p lint.Problem) {
+	if len(p.Edits) == 0 {
+		t.Errorf("Lint failed at %s:%d; expected fix `%s` but problem has none", filename, in.Line, in.Replacement)
+		return
+	}
+	fixed, err := lint.ApplyEdits(src, p.Edits)
+	if err != nil {
+		t.Errorf("Lint failed at %s:%d; couldn't apply fix: %s", filename, in.Line, err)
+		return
+	}
+	// The edits may span multiple lines, in which case the
+	// replacement covers all lines that changed, starting at the
+	// problem's line.
+	lines := strings.Split(string(src), "\n")
+	flines := strings.Split(string(fixed), "\n")
+	n := len(flines) - len(lines) + 1
+	if in.Line-1 < 0 || n < 1 || in.Line-1+n > len(flines) {
+		t.Errorf("Lint failed at %s:%d; fix produced unexpected output", filename, in.Line)
+		return
+	}
+	got := strings.Join(flines[in.Line-1:in.Line-1+n], "\n")
+	if i := strings.Index(got, "// MATCH"); i >= 0 {
+		got = got[:i]
+	}
+	got = strings.TrimSpace(got)
+	if got != in.Replacement {
+		t.Errorf("Lint failed at %s:%d; fix produced `%s`, want `%s`", filename, in.Line, got, in.Replacement)
+	}
+}
+
 type instruction struct {
 	Line        int            // the line number this applies to
 	Match       *regexp.Regexp // what pattern to match
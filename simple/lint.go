@@ -2,6 +2,7 @@
 package simple // import "honnef.co/go/tools/simple"
 
 import (
+	"fmt"
 	"go/ast"
 	"go/constant"
 	"go/token"
@@ -31,38 +32,42 @@ func NewChecker() *Checker {
 func (*Checker) Name() string   { return "gosimple" }
 func (*Checker) Prefix() string { return "S" }
 
+func (c *Checker) CacheKey() string {
+	return fmt.Sprintf("check generated %t", c.CheckGenerated)
+}
+
 func (c *Checker) Init(prog *lint.Program) {}
 
 func (c *Checker) Checks() []lint.Check {
 	return []lint.Check{
-		{ID: "S1000", FilterGenerated: true, Fn: c.LintSingleCaseSelect},
-		{ID: "S1001", FilterGenerated: true, Fn: c.LintLoopCopy},
-		{ID: "S1002", FilterGenerated: true, Fn: c.LintIfBoolCmp},
-		{ID: "S1003", FilterGenerated: true, Fn: c.LintStringsContains},
-		{ID: "S1004", FilterGenerated: true, Fn: c.LintBytesCompare},
-		{ID: "S1005", FilterGenerated: true, Fn: c.LintUnnecessaryBlank},
-		{ID: "S1006", FilterGenerated: true, Fn: c.LintForTrue},
-		{ID: "S1007", FilterGenerated: true, Fn: c.LintRegexpRaw},
-		{ID: "S1008", FilterGenerated: true, Fn: c.LintIfReturn},
-		{ID: "S1009", FilterGenerated: true, Fn: c.LintRedundantNilCheckWithLen},
-		{ID: "S1010", FilterGenerated: true, Fn: c.LintSlicing},
-		{ID: "S1011", FilterGenerated: true, Fn: c.LintLoopAppend},
-		{ID: "S1012", FilterGenerated: true, Fn: c.LintTimeSince},
-		{ID: "S1016", FilterGenerated: true, Fn: c.LintSimplerStructConversion},
-		{ID: "S1017", FilterGenerated: true, Fn: c.LintTrim},
-		{ID: "S1018", FilterGenerated: true, Fn: c.LintLoopSlide},
-		{ID: "S1019", FilterGenerated: true, Fn: c.LintMakeLenCap},
-		{ID: "S1020", FilterGenerated: true, Fn: c.LintAssertNotNil},
-		{ID: "S1021", FilterGenerated: true, Fn: c.LintDeclareAssign},
-		{ID: "S1023", FilterGenerated: true, Fn: c.LintRedundantBreak},
-		{ID: "S1024", FilterGenerated: true, Fn: c.LintTimeUntil},
-		{ID: "S1025", FilterGenerated: true, Fn: c.LintRedundantSprintf},
-		{ID: "S1028", FilterGenerated: true, Fn: c.LintErrorsNewSprintf},
-		{ID: "S1029", FilterGenerated: false, Fn: c.LintRangeStringRunes},
-		{ID: "S1030", FilterGenerated: true, Fn: c.LintBytesBufferConversions},
-		{ID: "S1031", FilterGenerated: true, Fn: c.LintNilCheckAroundRange},
-		{ID: "S1032", FilterGenerated: true, Fn: c.LintSortHelpers},
-		{ID: "S1033", FilterGenerated: true, Fn: c.LintGuardedDelete},
+		{ID: "S1000", FilterGenerated: true, Fn: c.LintSingleCaseSelect, Doc: docs["S1000"]},
+		{ID: "S1001", FilterGenerated: true, Fn: c.LintLoopCopy, Doc: docs["S1001"]},
+		{ID: "S1002", FilterGenerated: true, Fn: c.LintIfBoolCmp, Doc: docs["S1002"]},
+		{ID: "S1003", FilterGenerated: true, Fn: c.LintStringsContains, Doc: docs["S1003"]},
+		{ID: "S1004", FilterGenerated: true, Fn: c.LintBytesCompare, Doc: docs["S1004"]},
+		{ID: "S1005", FilterGenerated: true, Fn: c.LintUnnecessaryBlank, Doc: docs["S1005"]},
+		{ID: "S1006", FilterGenerated: true, Fn: c.LintForTrue, Doc: docs["S1006"]},
+		{ID: "S1007", FilterGenerated: true, Fn: c.LintRegexpRaw, Doc: docs["S1007"]},
+		{ID: "S1008", FilterGenerated: true, Fn: c.LintIfReturn, Doc: docs["S1008"]},
+		{ID: "S1009", FilterGenerated: true, Fn: c.LintRedundantNilCheckWithLen, Doc: docs["S1009"]},
+		{ID: "S1010", FilterGenerated: true, Fn: c.LintSlicing, Doc: docs["S1010"]},
+		{ID: "S1011", FilterGenerated: true, Fn: c.LintLoopAppend, Doc: docs["S1011"]},
+		{ID: "S1012", FilterGenerated: true, Fn: c.LintTimeSince, Doc: docs["S1012"]},
+		{ID: "S1016", FilterGenerated: true, Fn: c.LintSimplerStructConversion, Doc: docs["S1016"]},
+		{ID: "S1017", FilterGenerated: true, Fn: c.LintTrim, Doc: docs["S1017"]},
+		{ID: "S1018", FilterGenerated: true, Fn: c.LintLoopSlide, Doc: docs["S1018"]},
+		{ID: "S1019", FilterGenerated: true, Fn: c.LintMakeLenCap, Doc: docs["S1019"]},
+		{ID: "S1020", FilterGenerated: true, Fn: c.LintAssertNotNil, Doc: docs["S1020"]},
+		{ID: "S1021", FilterGenerated: true, Fn: c.LintDeclareAssign, Doc: docs["S1021"]},
+		{ID: "S1023", FilterGenerated: true, Fn: c.LintRedundantBreak, Doc: docs["S1023"]},
+		{ID: "S1024", FilterGenerated: true, Fn: c.LintTimeUntil, Doc: docs["S1024"]},
+		{ID: "S1025", FilterGenerated: true, Fn: c.LintRedundantSprintf, Doc: docs["S1025"]},
+		{ID: "S1028", FilterGenerated: true, Fn: c.LintErrorsNewSprintf, Doc: docs["S1028"]},
+		{ID: "S1029", FilterGenerated: false, Fn: c.LintRangeStringRunes, Doc: docs["S1029"]},
+		{ID: "S1030", FilterGenerated: true, Fn: c.LintBytesBufferConversions, Doc: docs["S1030"]},
+		{ID: "S1031", FilterGenerated: true, Fn: c.LintNilCheckAroundRange, Doc: docs["S1031"]},
+		{ID: "S1032", FilterGenerated: true, Fn: c.LintSortHelpers, Doc: docs["S1032"]},
+		{ID: "S1033", FilterGenerated: true, Fn: c.LintGuardedDelete, Doc: docs["S1033"]},
 	}
 }
 
@@ -213,11 +218,18 @@ func (c *Checker) LintIfBoolCmp(j *lint.Job) {
 		if !ok || basic.Kind() != types.Bool {
 			return true
 		}
-		op := ""
+		r := Render(j, other)
 		if (expr.Op == token.EQL && !val) || (expr.Op == token.NEQ && val) {
-			op = "!"
+			switch other.(type) {
+			case *ast.Ident, *ast.CallExpr, *ast.SelectorExpr, *ast.IndexExpr,
+				*ast.UnaryExpr, *ast.ParenExpr, *ast.StarExpr, *ast.TypeAssertExpr:
+			default:
+				// Negating binary expressions, such as a < b,
+				// requires parentheses.
+				r = "(" + r + ")"
+			}
+			r = "!" + r
 		}
-		r := op + Render(j, other)
 		l1 := len(r)
 		r = strings.TrimLeft(r, "!")
 		if (l1-len(r))%2 == 1 {
@@ -226,7 +238,8 @@ func (c *Checker) LintIfBoolCmp(j *lint.Job) {
 		if IsInTest(j, node) {
 			return true
 		}
-		j.Errorf(expr, "should omit comparison to bool constant, can be simplified to %s", r)
+		j.ErrorfWithFix(expr, []lint.Edit{j.Edit(expr, r)},
+			"should omit comparison to bool constant, can be simplified to %s", r)
 		return true
 	}
 	for _, f := range j.Program.Files {
@@ -252,9 +265,11 @@ func (c *Checker) LintBytesBufferConversions(j *lint.Job) {
 
 		typ := TypeOf(j, call.Fun)
 		if typ == types.Universe.Lookup("string").Type() && IsCallToAST(j, call.Args[0], "(*bytes.Buffer).Bytes") {
-			j.Errorf(call, "should use %v.String() instead of %v", Render(j, sel.X), Render(j, call))
+			r := Render(j, sel.X) + ".String()"
+			j.ErrorfWithFix(call, []lint.Edit{j.Edit(call, r)}, "should use %v instead of %v", r, Render(j, call))
 		} else if typ, ok := typ.(*types.Slice); ok && typ.Elem() == types.Universe.Lookup("byte").Type() && IsCallToAST(j, call.Args[0], "(*bytes.Buffer).String") {
-			j.Errorf(call, "should use %v.Bytes() instead of %v", Render(j, sel.X), Render(j, call))
+			r := Render(j, sel.X) + ".Bytes()"
+			j.ErrorfWithFix(call, []lint.Edit{j.Edit(call, r)}, "should use %v instead of %v", r, Render(j, call))
 		}
 
 		return true
@@ -327,7 +342,8 @@ func (c *Checker) LintStringsContains(j *lint.Job) {
 		if !b {
 			prefix = "!"
 		}
-		j.Errorf(node, "should use %s%s.%s(%s) instead", prefix, pkgIdent.Name, newFunc, RenderArgs(j, call.Args))
+		r := fmt.Sprintf("%s%s.%s(%s)", prefix, pkgIdent.Name, newFunc, RenderArgs(j, call.Args))
+		j.ErrorfWithFix(node, []lint.Edit{j.Edit(expr, r)}, "should use %s instead", r)
 
 		return true
 	}
@@ -361,7 +377,13 @@ func (c *Checker) LintBytesCompare(j *lint.Job) {
 		if expr.Op == token.NEQ {
 			prefix = "!"
 		}
-		j.Errorf(node, "should use %sbytes.Equal(%s) instead", prefix, args)
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			j.Errorf(node, "should use %sbytes.Equal(%s) instead", prefix, args)
+			return true
+		}
+		r := fmt.Sprintf("%s%s.Equal(%s)", prefix, Render(j, sel.X), args)
+		j.ErrorfWithFix(node, []lint.Edit{j.Edit(expr, r)}, "should use %s instead", r)
 		return true
 	}
 	for _, f := range j.Program.Files {
@@ -679,7 +701,10 @@ func (c *Checker) LintSlicing(j *lint.Job) {
 		if !ok || arg.Obj != s.Obj {
 			return true
 		}
-		j.Errorf(n, "should omit second index in slice, s[a:len(s)] is identical to s[a:]")
+		cp := *n
+		cp.High = nil
+		j.ErrorfWithFix(n, []lint.Edit{j.Edit(n, Render(j, &cp))},
+			"should omit second index in slice, s[a:len(s)] is identical to s[a:]")
 		return true
 	}
 	for _, f := range j.Program.Files {
@@ -794,7 +819,15 @@ func (c *Checker) LintTimeSince(j *lint.Job) {
 		if sel.Sel.Name != "Sub" {
 			return true
 		}
-		j.Errorf(call, "should use time.Since instead of time.Now().Sub")
+		// Reuse the time package's identifier from the call to
+		// time.Now so that renamed imports keep working.
+		now, ok := sel.X.(*ast.CallExpr).Fun.(*ast.SelectorExpr)
+		if !ok {
+			j.Errorf(call, "should use time.Since instead of time.Now().Sub")
+			return true
+		}
+		r := fmt.Sprintf("%s.Since(%s)", Render(j, now.X), RenderArgs(j, call.Args))
+		j.ErrorfWithFix(call, []lint.Edit{j.Edit(call, r)}, "should use time.Since instead of time.Now().Sub")
 		return true
 	}
 	for _, f := range j.Program.Files {
@@ -803,14 +836,14 @@ func (c *Checker) LintTimeSince(j *lint.Job) {
 }
 
 func (c *Checker) LintTimeUntil(j *lint.Job) {
-	if !IsGoVersion(j, 8) {
-		return
-	}
 	fn := func(node ast.Node) bool {
 		call, ok := node.(*ast.CallExpr)
 		if !ok {
 			return true
 		}
+		if !IsGoVersion(j, call, 8) {
+			return true
+		}
 		if !IsCallToAST(j, call, "(time.Time).Sub") {
 			return true
 		}
@@ -853,7 +886,8 @@ func (c *Checker) LintUnnecessaryBlank(j *lint.Job) {
 		}
 		cp := *assign
 		cp.Lhs = cp.Lhs[0:1]
-		j.Errorf(assign, "should write %s instead of %s", Render(j, &cp), Render(j, assign))
+		r := Render(j, &cp)
+		j.ErrorfWithFix(assign, []lint.Edit{j.Edit(assign, r)}, "should write %s instead of %s", r, Render(j, assign))
 	}
 
 	fn2 := func(node ast.Node) {
@@ -882,7 +916,7 @@ func (c *Checker) LintUnnecessaryBlank(j *lint.Job) {
 
 	fn3 := func(node ast.Node) {
 		rs, ok := node.(*ast.RangeStmt)
-		if !ok {
+		if !ok || !IsGoVersion(j, rs, 4) {
 			return
 		}
 
@@ -899,9 +933,7 @@ func (c *Checker) LintUnnecessaryBlank(j *lint.Job) {
 	fn := func(node ast.Node) bool {
 		fn1(node)
 		fn2(node)
-		if IsGoVersion(j, 4) {
-			fn3(node)
-		}
+		fn3(node)
 		return true
 	}
 	for _, f := range j.Program.Files {
@@ -1016,7 +1048,7 @@ func (c *Checker) LintSimplerStructConversion(j *lint.Job) {
 		if typ1 == typ2 {
 			return true
 		}
-		if IsGoVersion(j, 8) {
+		if IsGoVersion(j, lit, 8) {
 			if !types.IdenticalIgnoreTags(s1, s2) {
 				return true
 			}
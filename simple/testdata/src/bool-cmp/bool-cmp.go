@@ -9,7 +9,7 @@ func fn() {
 	const t T = false
 	if x == t {
 	}
-	if fn1() == true { // MATCH "simplified to fn1()"
+	if fn1() == true { // MATCH /simplified to fn1\(\)/ -> `if fn1() {`
 	}
 	if fn1() != true { // MATCH "simplified to !fn1()"
 	}
@@ -23,6 +23,14 @@ func fn() {
 	if (fn1() && fn2()) == false { // MATCH "simplified to !(fn1() && fn2())"
 	}
 
+	var a, b int
+	if a < b == false { // MATCH /simplified to !\(a < b\)/ -> `if !(a < b) {`
+	}
+	if a < b != true { // MATCH "simplified to !(a < b)"
+	}
+	if a < b == true { // MATCH "simplified to a < b"
+	}
+
 	var y bool
 	for y != true { // MATCH /simplified to !y/
 	}
@@ -36,7 +44,7 @@ func fn() {
 	}
 	if !!y == false { // MATCH /simplified to !y/
 	}
-	if !!!y == false { // MATCH /simplified to y/
+	if !!!y == false { // MATCH /simplified to y/ -> `if y {`
 	}
 	if !!y == true { // MATCH /simplified to y/
 	}
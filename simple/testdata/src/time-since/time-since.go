@@ -4,6 +4,6 @@ import "time"
 
 func fn() {
 	t1 := time.Now()
-	_ = time.Now().Sub(t1) // MATCH "time.Since"
+	_ = time.Now().Sub(t1) // MATCH /time.Since/ -> `_ = time.Since(t1)`
 	_ = time.Date(0, 0, 0, 0, 0, 0, 0, nil).Sub(t1)
 }
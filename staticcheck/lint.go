@@ -28,7 +28,6 @@ import (
 	"honnef.co/go/tools/staticcheck/vrp"
 
 	"golang.org/x/tools/go/ast/astutil"
-	"golang.org/x/tools/go/packages"
 )
 
 func validRegexp(call *Call) {
@@ -103,7 +102,7 @@ var (
 	checkEncodingBinaryRules = map[string]CallCheck{
 		"encoding/binary.Write": func(call *Call) {
 			arg := call.Args[Arg("encoding/binary.Write.data")]
-			if !CanBinaryMarshal(call.Job, arg.Value) {
+			if !CanBinaryMarshal(call.Job, call.Parent, arg.Value) {
 				arg.Invalid(fmt.Sprintf("value of type %s cannot be used with binary.Write", arg.Value.Value.Type()))
 			}
 		},
@@ -219,80 +218,84 @@ func NewChecker() *Checker {
 func (*Checker) Name() string   { return "staticcheck" }
 func (*Checker) Prefix() string { return "SA" }
 
+func (c *Checker) CacheKey() string {
+	return fmt.Sprintf("check generated %t", c.CheckGenerated)
+}
+
 func (c *Checker) Checks() []lint.Check {
 	return []lint.Check{
-		{ID: "SA1000", FilterGenerated: false, Fn: c.callChecker(checkRegexpRules)},
-		{ID: "SA1001", FilterGenerated: false, Fn: c.CheckTemplate},
-		{ID: "SA1002", FilterGenerated: false, Fn: c.callChecker(checkTimeParseRules)},
-		{ID: "SA1003", FilterGenerated: false, Fn: c.callChecker(checkEncodingBinaryRules)},
-		{ID: "SA1004", FilterGenerated: false, Fn: c.CheckTimeSleepConstant},
-		{ID: "SA1005", FilterGenerated: false, Fn: c.CheckExec},
-		{ID: "SA1006", FilterGenerated: false, Fn: c.CheckUnsafePrintf},
-		{ID: "SA1007", FilterGenerated: false, Fn: c.callChecker(checkURLsRules)},
-		{ID: "SA1008", FilterGenerated: false, Fn: c.CheckCanonicalHeaderKey},
-		{ID: "SA1010", FilterGenerated: false, Fn: c.callChecker(checkRegexpFindAllRules)},
-		{ID: "SA1011", FilterGenerated: false, Fn: c.callChecker(checkUTF8CutsetRules)},
-		{ID: "SA1012", FilterGenerated: false, Fn: c.CheckNilContext},
-		{ID: "SA1013", FilterGenerated: false, Fn: c.CheckSeeker},
-		{ID: "SA1014", FilterGenerated: false, Fn: c.callChecker(checkUnmarshalPointerRules)},
-		{ID: "SA1015", FilterGenerated: false, Fn: c.CheckLeakyTimeTick},
-		{ID: "SA1016", FilterGenerated: false, Fn: c.CheckUntrappableSignal},
-		{ID: "SA1017", FilterGenerated: false, Fn: c.callChecker(checkUnbufferedSignalChanRules)},
-		{ID: "SA1018", FilterGenerated: false, Fn: c.callChecker(checkStringsReplaceZeroRules)},
-		{ID: "SA1019", FilterGenerated: false, Fn: c.CheckDeprecated},
-		{ID: "SA1020", FilterGenerated: false, Fn: c.callChecker(checkListenAddressRules)},
-		{ID: "SA1021", FilterGenerated: false, Fn: c.callChecker(checkBytesEqualIPRules)},
-		{ID: "SA1023", FilterGenerated: false, Fn: c.CheckWriterBufferModified},
-		{ID: "SA1024", FilterGenerated: false, Fn: c.callChecker(checkUniqueCutsetRules)},
-		{ID: "SA1025", FilterGenerated: false, Fn: c.CheckTimerResetReturnValue},
-
-		{ID: "SA2000", FilterGenerated: false, Fn: c.CheckWaitgroupAdd},
-		{ID: "SA2001", FilterGenerated: false, Fn: c.CheckEmptyCriticalSection},
-		{ID: "SA2002", FilterGenerated: false, Fn: c.CheckConcurrentTesting},
-		{ID: "SA2003", FilterGenerated: false, Fn: c.CheckDeferLock},
-
-		{ID: "SA3000", FilterGenerated: false, Fn: c.CheckTestMainExit},
-		{ID: "SA3001", FilterGenerated: false, Fn: c.CheckBenchmarkN},
-
-		{ID: "SA4000", FilterGenerated: false, Fn: c.CheckLhsRhsIdentical},
-		{ID: "SA4001", FilterGenerated: false, Fn: c.CheckIneffectiveCopy},
-		{ID: "SA4002", FilterGenerated: false, Fn: c.CheckDiffSizeComparison},
-		{ID: "SA4003", FilterGenerated: false, Fn: c.CheckExtremeComparison},
-		{ID: "SA4004", FilterGenerated: false, Fn: c.CheckIneffectiveLoop},
-		{ID: "SA4006", FilterGenerated: false, Fn: c.CheckUnreadVariableValues},
-		{ID: "SA4008", FilterGenerated: false, Fn: c.CheckLoopCondition},
-		{ID: "SA4009", FilterGenerated: false, Fn: c.CheckArgOverwritten},
-		{ID: "SA4010", FilterGenerated: false, Fn: c.CheckIneffectiveAppend},
-		{ID: "SA4011", FilterGenerated: false, Fn: c.CheckScopedBreak},
-		{ID: "SA4012", FilterGenerated: false, Fn: c.CheckNaNComparison},
-		{ID: "SA4013", FilterGenerated: false, Fn: c.CheckDoubleNegation},
-		{ID: "SA4014", FilterGenerated: false, Fn: c.CheckRepeatedIfElse},
-		{ID: "SA4015", FilterGenerated: false, Fn: c.callChecker(checkMathIntRules)},
-		{ID: "SA4016", FilterGenerated: false, Fn: c.CheckSillyBitwiseOps},
-		{ID: "SA4017", FilterGenerated: false, Fn: c.CheckPureFunctions},
-		{ID: "SA4018", FilterGenerated: true, Fn: c.CheckSelfAssignment},
-		{ID: "SA4019", FilterGenerated: true, Fn: c.CheckDuplicateBuildConstraints},
-		{ID: "SA4020", FilterGenerated: false, Fn: c.CheckUnreachableTypeCases},
-
-		{ID: "SA5000", FilterGenerated: false, Fn: c.CheckNilMaps},
-		{ID: "SA5001", FilterGenerated: false, Fn: c.CheckEarlyDefer},
-		{ID: "SA5002", FilterGenerated: false, Fn: c.CheckInfiniteEmptyLoop},
-		{ID: "SA5003", FilterGenerated: false, Fn: c.CheckDeferInInfiniteLoop},
-		{ID: "SA5004", FilterGenerated: false, Fn: c.CheckLoopEmptyDefault},
-		{ID: "SA5005", FilterGenerated: false, Fn: c.CheckCyclicFinalizer},
-		{ID: "SA5007", FilterGenerated: false, Fn: c.CheckInfiniteRecursion},
-
-		{ID: "SA6000", FilterGenerated: false, Fn: c.callChecker(checkRegexpMatchLoopRules)},
-		{ID: "SA6001", FilterGenerated: false, Fn: c.CheckMapBytesKey},
-		{ID: "SA6002", FilterGenerated: false, Fn: c.callChecker(checkSyncPoolValueRules)},
-		{ID: "SA6003", FilterGenerated: false, Fn: c.CheckRangeStringRunes},
+		{ID: "SA1000", FilterGenerated: false, Fn: c.callChecker(checkRegexpRules), Doc: docs["SA1000"]},
+		{ID: "SA1001", FilterGenerated: false, Fn: c.CheckTemplate, Doc: docs["SA1001"]},
+		{ID: "SA1002", FilterGenerated: false, Fn: c.callChecker(checkTimeParseRules), Doc: docs["SA1002"]},
+		{ID: "SA1003", FilterGenerated: false, Fn: c.callChecker(checkEncodingBinaryRules), Doc: docs["SA1003"]},
+		{ID: "SA1004", FilterGenerated: false, Fn: c.CheckTimeSleepConstant, Doc: docs["SA1004"]},
+		{ID: "SA1005", FilterGenerated: false, Fn: c.CheckExec, Doc: docs["SA1005"]},
+		{ID: "SA1006", FilterGenerated: false, Fn: c.CheckUnsafePrintf, Doc: docs["SA1006"]},
+		{ID: "SA1007", FilterGenerated: false, Fn: c.callChecker(checkURLsRules), Doc: docs["SA1007"]},
+		{ID: "SA1008", FilterGenerated: false, Fn: c.CheckCanonicalHeaderKey, Doc: docs["SA1008"]},
+		{ID: "SA1010", FilterGenerated: false, Fn: c.callChecker(checkRegexpFindAllRules), Doc: docs["SA1010"]},
+		{ID: "SA1011", FilterGenerated: false, Fn: c.callChecker(checkUTF8CutsetRules), Doc: docs["SA1011"]},
+		{ID: "SA1012", FilterGenerated: false, Fn: c.CheckNilContext, Doc: docs["SA1012"]},
+		{ID: "SA1013", FilterGenerated: false, Fn: c.CheckSeeker, Doc: docs["SA1013"]},
+		{ID: "SA1014", FilterGenerated: false, Fn: c.callChecker(checkUnmarshalPointerRules), Doc: docs["SA1014"]},
+		{ID: "SA1015", FilterGenerated: false, Fn: c.CheckLeakyTimeTick, Doc: docs["SA1015"]},
+		{ID: "SA1016", FilterGenerated: false, Fn: c.CheckUntrappableSignal, Doc: docs["SA1016"]},
+		{ID: "SA1017", FilterGenerated: false, Fn: c.callChecker(checkUnbufferedSignalChanRules), Doc: docs["SA1017"]},
+		{ID: "SA1018", FilterGenerated: false, Fn: c.callChecker(checkStringsReplaceZeroRules), Doc: docs["SA1018"]},
+		{ID: "SA1019", FilterGenerated: false, Fn: c.CheckDeprecated, Doc: docs["SA1019"]},
+		{ID: "SA1020", FilterGenerated: false, Fn: c.callChecker(checkListenAddressRules), Doc: docs["SA1020"]},
+		{ID: "SA1021", FilterGenerated: false, Fn: c.callChecker(checkBytesEqualIPRules), Doc: docs["SA1021"]},
+		{ID: "SA1023", FilterGenerated: false, Fn: c.CheckWriterBufferModified, Doc: docs["SA1023"]},
+		{ID: "SA1024", FilterGenerated: false, Fn: c.callChecker(checkUniqueCutsetRules), Doc: docs["SA1024"]},
+		{ID: "SA1025", FilterGenerated: false, Fn: c.CheckTimerResetReturnValue, Doc: docs["SA1025"]},
+
+		{ID: "SA2000", FilterGenerated: false, Fn: c.CheckWaitgroupAdd, Doc: docs["SA2000"]},
+		{ID: "SA2001", FilterGenerated: false, Fn: c.CheckEmptyCriticalSection, Doc: docs["SA2001"]},
+		{ID: "SA2002", FilterGenerated: false, Fn: c.CheckConcurrentTesting, Doc: docs["SA2002"]},
+		{ID: "SA2003", FilterGenerated: false, Fn: c.CheckDeferLock, Doc: docs["SA2003"]},
+
+		{ID: "SA3000", FilterGenerated: false, Fn: c.CheckTestMainExit, Doc: docs["SA3000"]},
+		{ID: "SA3001", FilterGenerated: false, Fn: c.CheckBenchmarkN, Doc: docs["SA3001"]},
+
+		{ID: "SA4000", FilterGenerated: false, Fn: c.CheckLhsRhsIdentical, Doc: docs["SA4000"]},
+		{ID: "SA4001", FilterGenerated: false, Fn: c.CheckIneffectiveCopy, Doc: docs["SA4001"]},
+		{ID: "SA4002", FilterGenerated: false, Fn: c.CheckDiffSizeComparison, Doc: docs["SA4002"]},
+		{ID: "SA4003", FilterGenerated: false, Fn: c.CheckExtremeComparison, Doc: docs["SA4003"]},
+		{ID: "SA4004", FilterGenerated: false, Fn: c.CheckIneffectiveLoop, Doc: docs["SA4004"]},
+		{ID: "SA4006", FilterGenerated: false, Fn: c.CheckUnreadVariableValues, Doc: docs["SA4006"]},
+		{ID: "SA4008", FilterGenerated: false, Fn: c.CheckLoopCondition, Doc: docs["SA4008"]},
+		{ID: "SA4009", FilterGenerated: false, Fn: c.CheckArgOverwritten, Doc: docs["SA4009"]},
+		{ID: "SA4010", FilterGenerated: false, Fn: c.CheckIneffectiveAppend, Doc: docs["SA4010"]},
+		{ID: "SA4011", FilterGenerated: false, Fn: c.CheckScopedBreak, Doc: docs["SA4011"]},
+		{ID: "SA4012", FilterGenerated: false, Fn: c.CheckNaNComparison, Doc: docs["SA4012"]},
+		{ID: "SA4013", FilterGenerated: false, Fn: c.CheckDoubleNegation, Doc: docs["SA4013"]},
+		{ID: "SA4014", FilterGenerated: false, Fn: c.CheckRepeatedIfElse, Doc: docs["SA4014"]},
+		{ID: "SA4015", FilterGenerated: false, Fn: c.callChecker(checkMathIntRules), Doc: docs["SA4015"]},
+		{ID: "SA4016", FilterGenerated: false, Fn: c.CheckSillyBitwiseOps, Doc: docs["SA4016"]},
+		{ID: "SA4017", FilterGenerated: false, Fn: c.CheckPureFunctions, Doc: docs["SA4017"]},
+		{ID: "SA4018", FilterGenerated: true, Fn: c.CheckSelfAssignment, Doc: docs["SA4018"]},
+		{ID: "SA4019", FilterGenerated: true, Fn: c.CheckDuplicateBuildConstraints, Doc: docs["SA4019"]},
+		{ID: "SA4020", FilterGenerated: false, Fn: c.CheckUnreachableTypeCases, Doc: docs["SA4020"]},
+
+		{ID: "SA5000", FilterGenerated: false, Fn: c.CheckNilMaps, Doc: docs["SA5000"]},
+		{ID: "SA5001", FilterGenerated: false, Fn: c.CheckEarlyDefer, Doc: docs["SA5001"]},
+		{ID: "SA5002", FilterGenerated: false, Fn: c.CheckInfiniteEmptyLoop, Doc: docs["SA5002"]},
+		{ID: "SA5003", FilterGenerated: false, Fn: c.CheckDeferInInfiniteLoop, Doc: docs["SA5003"]},
+		{ID: "SA5004", FilterGenerated: false, Fn: c.CheckLoopEmptyDefault, Doc: docs["SA5004"]},
+		{ID: "SA5005", FilterGenerated: false, Fn: c.CheckCyclicFinalizer, Doc: docs["SA5005"]},
+		{ID: "SA5007", FilterGenerated: false, Fn: c.CheckInfiniteRecursion, Doc: docs["SA5007"]},
+
+		{ID: "SA6000", FilterGenerated: false, Fn: c.callChecker(checkRegexpMatchLoopRules), Doc: docs["SA6000"]},
+		{ID: "SA6001", FilterGenerated: false, Fn: c.CheckMapBytesKey, Doc: docs["SA6001"]},
+		{ID: "SA6002", FilterGenerated: false, Fn: c.callChecker(checkSyncPoolValueRules), Doc: docs["SA6002"]},
+		{ID: "SA6003", FilterGenerated: false, Fn: c.CheckRangeStringRunes, Doc: docs["SA6003"]},
 		// {ID: "SA6004", FilterGenerated: false, Fn: c.CheckSillyRegexp},
-		{ID: "SA6005", FilterGenerated: false, Fn: c.CheckToLowerToUpperComparison},
+		{ID: "SA6005", FilterGenerated: false, Fn: c.CheckToLowerToUpperComparison, Doc: docs["SA6005"]},
 
-		{ID: "SA9001", FilterGenerated: false, Fn: c.CheckDubiousDeferInChannelRangeLoop},
-		{ID: "SA9002", FilterGenerated: false, Fn: c.CheckNonOctalFileMode},
-		{ID: "SA9003", FilterGenerated: false, Fn: c.CheckEmptyBranch},
-		{ID: "SA9004", FilterGenerated: false, Fn: c.CheckMissingEnumTypesInDeclaration},
+		{ID: "SA9001", FilterGenerated: false, Fn: c.CheckDubiousDeferInChannelRangeLoop, Doc: docs["SA9001"]},
+		{ID: "SA9002", FilterGenerated: false, Fn: c.CheckNonOctalFileMode, Doc: docs["SA9002"]},
+		{ID: "SA9003", FilterGenerated: false, Fn: c.CheckEmptyBranch, Doc: docs["SA9003"]},
+		{ID: "SA9004", FilterGenerated: false, Fn: c.CheckMissingEnumTypesInDeclaration, Doc: docs["SA9004"]},
 	}
 
 	// "SA5006": c.CheckSliceOutOfBounds,
@@ -300,87 +303,9 @@ func (c *Checker) Checks() []lint.Check {
 }
 
 func (c *Checker) findDeprecated(prog *lint.Program) {
-	var docs []*ast.CommentGroup
-	var names []*ast.Ident
-
-	doDocs := func(pkg *packages.Package, names []*ast.Ident, docs []*ast.CommentGroup) {
-		var alt string
-		for _, doc := range docs {
-			if doc == nil {
-				continue
-			}
-			parts := strings.Split(doc.Text(), "\n\n")
-			last := parts[len(parts)-1]
-			if !strings.HasPrefix(last, "Deprecated: ") {
-				continue
-			}
-			alt = last[len("Deprecated: "):]
-			alt = strings.Replace(alt, "\n", " ", -1)
-			break
-		}
-		if alt == "" {
-			return
-		}
-
-		for _, name := range names {
-			obj := pkg.TypesInfo.ObjectOf(name)
-			c.deprecatedObjs[obj] = alt
-		}
-	}
-
 	for _, pkg := range prog.AllPackages {
-		for _, f := range pkg.Syntax {
-			fn := func(node ast.Node) bool {
-				if node == nil {
-					return true
-				}
-				var ret bool
-				switch node := node.(type) {
-				case *ast.GenDecl:
-					switch node.Tok {
-					case token.TYPE, token.CONST, token.VAR:
-						docs = append(docs, node.Doc)
-						return true
-					default:
-						return false
-					}
-				case *ast.FuncDecl:
-					docs = append(docs, node.Doc)
-					names = []*ast.Ident{node.Name}
-					ret = false
-				case *ast.TypeSpec:
-					docs = append(docs, node.Doc)
-					names = []*ast.Ident{node.Name}
-					ret = true
-				case *ast.ValueSpec:
-					docs = append(docs, node.Doc)
-					names = node.Names
-					ret = false
-				case *ast.File:
-					return true
-				case *ast.StructType:
-					for _, field := range node.Fields.List {
-						doDocs(pkg, field.Names, []*ast.CommentGroup{field.Doc})
-					}
-					return false
-				case *ast.InterfaceType:
-					for _, field := range node.Methods.List {
-						doDocs(pkg, field.Names, []*ast.CommentGroup{field.Doc})
-					}
-					return false
-				default:
-					return false
-				}
-				if len(names) == 0 || len(docs) == 0 {
-					return ret
-				}
-				doDocs(pkg, names, docs)
-
-				docs = docs[:0]
-				names = nil
-				return ret
-			}
-			ast.Inspect(f, fn)
+		for obj, alt := range deprecated.Objects(pkg.Syntax, pkg.TypesInfo) {
+			c.deprecatedObjs[obj] = alt
 		}
 	}
 }
@@ -390,6 +315,11 @@ func (c *Checker) Init(prog *lint.Program) {
 	wg.Add(2)
 	go func() {
 		c.funcDescs = functions.NewDescriptions(prog.SSA)
+		if prog.ImportObjectFact != nil {
+			c.funcDescs.Imported = func(fn *types.Func, s *functions.Summary) bool {
+				return prog.ImportObjectFact(fn, s)
+			}
+		}
 		for _, fn := range prog.AllFunctions {
 			if fn.Blocks != nil {
 				applyStdlibKnowledge(fn)
@@ -1625,8 +1555,11 @@ func (c *Checker) CheckArgOverwritten(j *lint.Job) {
 						continue
 					}
 
-					assigned := false
+					var assignment ast.Node
 					ast.Inspect(body, func(node ast.Node) bool {
+						if assignment != nil {
+							return false
+						}
 						assign, ok := node.(*ast.AssignStmt)
 						if !ok {
 							return true
@@ -1637,14 +1570,15 @@ func (c *Checker) CheckArgOverwritten(j *lint.Job) {
 								continue
 							}
 							if ObjectOf(j, ident) == obj {
-								assigned = true
+								assignment = assign
 								return false
 							}
 						}
 						return true
 					})
-					if assigned {
-						j.Errorf(arg, "argument %s is overwritten before first use", arg)
+					if assignment != nil {
+						p := j.Errorf(arg, "argument %s is overwritten before first use", arg)
+						j.Relate(p, assignment, "%s is overwritten here", arg)
 					}
 				}
 			}
@@ -2380,6 +2314,12 @@ func (c *Checker) isDeprecated(j *lint.Job, ident *ast.Ident) (bool, string) {
 		return false, ""
 	}
 	alt := c.deprecatedObjs[obj]
+	if alt == "" && j.Program.ImportObjectFact != nil {
+		var fact deprecated.IsDeprecated
+		if j.Program.ImportObjectFact(obj, &fact) {
+			alt = fact.Msg
+		}
+	}
 	return alt != "", alt
 }
 
@@ -2423,7 +2363,7 @@ func (c *Checker) CheckDeprecated(j *lint.Job) {
 			// makes sense to use the alternative from 1.0, to be
 			// future-proof.
 			minVersion := deprecated.Stdlib[SelectorName(j, sel)].AlternativeAvailableSince
-			if !IsGoVersion(j, minVersion) {
+			if !IsGoVersion(j, sel, minVersion) {
 				return true
 			}
 
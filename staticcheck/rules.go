@@ -184,7 +184,9 @@ func ConvertedFromInt(v Value) bool {
 	return true
 }
 
-func validEncodingBinaryType(j *lint.Job, typ types.Type) bool {
+// validEncodingBinaryType reports whether typ can be used with
+// encoding/binary in the package containing node.
+func validEncodingBinaryType(j *lint.Job, node lint.Positioner, typ types.Type) bool {
 	typ = typ.Underlying()
 	switch typ := typ.(type) {
 	case *types.Basic:
@@ -194,19 +196,19 @@ func validEncodingBinaryType(j *lint.Job, typ types.Type) bool {
 			types.Float32, types.Float64, types.Complex64, types.Complex128, types.Invalid:
 			return true
 		case types.Bool:
-			return IsGoVersion(j, 8)
+			return IsGoVersion(j, node, 8)
 		}
 		return false
 	case *types.Struct:
 		n := typ.NumFields()
 		for i := 0; i < n; i++ {
-			if !validEncodingBinaryType(j, typ.Field(i).Type()) {
+			if !validEncodingBinaryType(j, node, typ.Field(i).Type()) {
 				return false
 			}
 		}
 		return true
 	case *types.Array:
-		return validEncodingBinaryType(j, typ.Elem())
+		return validEncodingBinaryType(j, node, typ.Elem())
 	case *types.Interface:
 		// we can't determine if it's a valid type or not
 		return true
@@ -214,7 +216,7 @@ func validEncodingBinaryType(j *lint.Job, typ types.Type) bool {
 	return false
 }
 
-func CanBinaryMarshal(j *lint.Job, v Value) bool {
+func CanBinaryMarshal(j *lint.Job, node lint.Positioner, v Value) bool {
 	typ := v.Value.Type().Underlying()
 	if ttyp, ok := typ.(*types.Pointer); ok {
 		typ = ttyp.Elem().Underlying()
@@ -227,7 +229,7 @@ func CanBinaryMarshal(j *lint.Job, v Value) bool {
 		}
 	}
 
-	return validEncodingBinaryType(j, typ)
+	return validEncodingBinaryType(j, node, typ)
 }
 
 func RepeatZeroTimes(name string, arg int) CallCheck {
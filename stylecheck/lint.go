@@ -30,25 +30,29 @@ func (*Checker) Name() string              { return "stylecheck" }
 func (*Checker) Prefix() string            { return "ST" }
 func (c *Checker) Init(prog *lint.Program) {}
 
+func (c *Checker) CacheKey() string {
+	return fmt.Sprintf("check generated %t", c.CheckGenerated)
+}
+
 func (c *Checker) Checks() []lint.Check {
 	return []lint.Check{
-		{ID: "ST1000", FilterGenerated: false, Fn: c.CheckPackageComment},
-		{ID: "ST1001", FilterGenerated: true, Fn: c.CheckDotImports},
+		{ID: "ST1000", FilterGenerated: false, Fn: c.CheckPackageComment, Doc: docs["ST1000"]},
+		{ID: "ST1001", FilterGenerated: true, Fn: c.CheckDotImports, Doc: docs["ST1001"]},
 		// {ID: "ST1002", FilterGenerated: true, Fn: c.CheckBlankImports},
-		{ID: "ST1003", FilterGenerated: true, Fn: c.CheckNames},
+		{ID: "ST1003", FilterGenerated: true, Fn: c.CheckNames, Doc: docs["ST1003"]},
 		// {ID: "ST1004", FilterGenerated: false, Fn: nil, 			  },
-		{ID: "ST1005", FilterGenerated: false, Fn: c.CheckErrorStrings},
-		{ID: "ST1006", FilterGenerated: false, Fn: c.CheckReceiverNames},
+		{ID: "ST1005", FilterGenerated: false, Fn: c.CheckErrorStrings, Doc: docs["ST1005"]},
+		{ID: "ST1006", FilterGenerated: false, Fn: c.CheckReceiverNames, Doc: docs["ST1006"]},
 		// {ID: "ST1007", FilterGenerated: true, Fn: c.CheckIncDec},
-		{ID: "ST1008", FilterGenerated: false, Fn: c.CheckErrorReturn},
+		{ID: "ST1008", FilterGenerated: false, Fn: c.CheckErrorReturn, Doc: docs["ST1008"]},
 		// {ID: "ST1009", FilterGenerated: false, Fn: c.CheckUnexportedReturn},
 		// {ID: "ST1010", FilterGenerated: false, Fn: c.CheckContextFirstArg},
-		{ID: "ST1011", FilterGenerated: false, Fn: c.CheckTimeNames},
-		{ID: "ST1012", FilterGenerated: false, Fn: c.CheckErrorVarNames},
-		{ID: "ST1013", FilterGenerated: true, Fn: c.CheckHTTPStatusCodes},
-		{ID: "ST1015", FilterGenerated: true, Fn: c.CheckDefaultCaseOrder},
-		{ID: "ST1016", FilterGenerated: false, Fn: c.CheckReceiverNamesIdentical},
-		{ID: "ST1017", FilterGenerated: true, Fn: c.CheckYodaConditions},
+		{ID: "ST1011", FilterGenerated: false, Fn: c.CheckTimeNames, Doc: docs["ST1011"]},
+		{ID: "ST1012", FilterGenerated: false, Fn: c.CheckErrorVarNames, Doc: docs["ST1012"]},
+		{ID: "ST1013", FilterGenerated: true, Fn: c.CheckHTTPStatusCodes, Doc: docs["ST1013"]},
+		{ID: "ST1015", FilterGenerated: true, Fn: c.CheckDefaultCaseOrder, Doc: docs["ST1015"]},
+		{ID: "ST1016", FilterGenerated: false, Fn: c.CheckReceiverNamesIdentical, Doc: docs["ST1016"]},
+		{ID: "ST1017", FilterGenerated: true, Fn: c.CheckYodaConditions, Doc: docs["ST1017"]},
 	}
 }
 
@@ -0,0 +1,17 @@
+package unused
+
+import "honnef.co/go/tools/lint"
+
+var docs = map[string]*lint.Documentation{
+	"U1000": {
+		Title: `Unused code`,
+		Text: `Identifiers, such as functions, types, fields and constants, that
+aren't used anywhere. In whole program mode, exported identifiers of
+packages that aren't part of the program are considered, too.`,
+		Since: "2017.1",
+	},
+}
+
+func init() {
+	lint.InitDocs(docs, map[string]string{"U": "Unused code"})
+}
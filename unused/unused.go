@@ -30,10 +30,18 @@ type LintChecker struct {
 func (*LintChecker) Name() string   { return "unused" }
 func (*LintChecker) Prefix() string { return "U" }
 
+func (l *LintChecker) CacheKey() string {
+	return fmt.Sprintf("mode %d whole program %t reflection %t", l.c.Mode, l.c.WholeProgram, l.c.ConsiderReflection)
+}
+
+func (l *LintChecker) WholeProgram() bool {
+	return l.c.WholeProgram
+}
+
 func (l *LintChecker) Init(*lint.Program) {}
 func (l *LintChecker) Checks() []lint.Check {
 	return []lint.Check{
-		{ID: "U1000", FilterGenerated: true, Fn: l.Lint},
+		{ID: "U1000", FilterGenerated: true, Fn: l.Lint, Doc: docs["U1000"]},
 	}
 }
 
@@ -499,6 +507,10 @@ func (c *Checker) findExportedInterfaces() {
 	}
 
 	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil {
+			// loaded from export data
+			continue
+		}
 		for _, tv := range pkg.TypesInfo.Types {
 			iface, ok := tv.Type.(*types.Interface)
 			if !ok {